@@ -1,15 +1,53 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/ws"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
 )
 
 func main() {
+	// Access keys are a JSON list, e.g. [{"id": "2024-06", "privateKeyPath": "...", "publicKeyPath": "..."}]
+	var accessKeys []authentication.AccessKey
+	if keys := os.Getenv("ACCESS_KEYS"); keys != "" {
+		if err := json.Unmarshal([]byte(keys), &accessKeys); err != nil {
+			fmt.Printf("Invalid ACCESS_KEYS: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Algorithms are a comma-separated list, e.g. RS256,PS256
+	var algorithms []string
+	if algs := os.Getenv("TOKEN_ALGORITHMS"); algs != "" {
+		algorithms = strings.Split(algs, ",")
+	}
+
 	hydratedConfig := webserver.Config{
-		Port: 3333,
+		Port:           3333,
+		RequestTimeout: 10 * time.Second,
+		Auth: authentication.Config{
+			AccessKeys:            accessKeys,
+			ActiveAccessKeyID:     os.Getenv("ACTIVE_ACCESS_KEY_ID"),
+			InternalPublicKeyPath: os.Getenv("INTERNAL_PUBLIC_KEY_PATH"),
+			Issuer:                os.Getenv("TOKEN_ISSUER"),
+			Audience:              os.Getenv("TOKEN_AUDIENCE"),
+			InternalIssuer:        os.Getenv("INTERNAL_TOKEN_ISSUER"),
+			Algorithms:            algorithms,
+		},
+		Storefront: storefront.Config{
+			Backend: os.Getenv("STOREFRONT_BACKEND"),
+			DSN:     os.Getenv("STOREFRONT_DSN"),
+		},
+		Hub: ws.Config{
+			MessagingPolicy: ws.PolicyFriends,
+		},
 	}
 
 	srv, err := webserver.New(hydratedConfig)
@@ -1,22 +1,20 @@
 package middleware
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
 	"regexp"
 	"strings"
 
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/utils"
 	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
 
 	"github.com/rs/zerolog/log"
 )
 
-type Response struct {
-	Status        string `json:"status"`
-	StatusCode    int    `json:"statusCode"`
-	StatusMessage string `json:"statusMessage,omitempty"`
-}
-
 type route struct {
 	Regex  string
 	Method string
@@ -26,11 +24,18 @@ var internalRoutes = [...]route{
 	{Regex: "^/auth/signin$", Method: http.MethodPost},
 	{Regex: "^/auth/tokens/access$", Method: http.MethodPost},
 	{Regex: "^/auth/tokens/access.*$", Method: http.MethodDelete},
+	{Regex: "^/auth/tokens/refresh$", Method: http.MethodPost},
 	{Regex: "^/users$", Method: http.MethodPost},
 	{Regex: "^/users/([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})|([^@]+@[^/]+)", Method: http.MethodGet},
 }
 
-// Authentication middleware
+// Routes anyone can request, without a token
+var publicRoutes = [...]route{
+	{Regex: "^/\\.well-known/jwks\\.json$", Method: http.MethodGet},
+}
+
+// Authentication middleware, parsing the token of a request once into the
+// principal handlers read with authentication.FromContext
 func Authentication(srv webserver.Server) func(h http.Handler) http.Handler {
 	if srv == nil {
 		log.Fatal().Msg("a nil dependency was passed to authentication middleware")
@@ -38,10 +43,13 @@ func Authentication(srv webserver.Server) func(h http.Handler) http.Handler {
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Content-Type", "application/json")
-
-			token := r.Header.Get("Authorization")
-			tokenString := strings.ReplaceAll(token, "Bearer ", "")
+			for _, route := range publicRoutes {
+				match, _ := regexp.MatchString(route.Regex, r.URL.String())
+				if match && r.Method == route.Method {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
 
 			// Check if the request URL requires an internal token
 			isInternalRoute := false
@@ -53,41 +61,54 @@ func Authentication(srv webserver.Server) func(h http.Handler) http.Handler {
 				}
 			}
 
-			res := Response{
-				Status:        "UNAUTHORIZED",
-				StatusCode:    401,
-				StatusMessage: "Invalid authorization token",
-			}
-
 			// Perform authentication middleware depending on the route
+			var principal authentication.Principal
+			var err error
 			if isInternalRoute {
-				if !srv.ValidateInternalJWT(tokenString) {
-					w.WriteHeader(http.StatusUnauthorized)
-					json.NewEncoder(w).Encode(&res)
-					return
-				}
+				tokenString := strings.ReplaceAll(r.Header.Get("Authorization"), "Bearer ", "")
+				principal, err = srv.ParseInternalJWT(r.Context(), tokenString)
+			} else if strings.HasPrefix(r.URL.String(), "/ws") {
+				// ws route authentication, the token is a query parameter
+				principal, err = srv.ParseJWT(r.Context(), r.URL.Query().Get("token"))
 			} else {
-				// ws route authentication
-				if strings.HasPrefix(r.URL.String(), "/ws") {
-					// Get the token from query parameter
-					authToken := r.URL.Query().Get("token")
+				var tokenString string
+				tokenString, err = utils.GetAuthorizationToken(r.Header.Get("Authorization"))
+				if err == nil {
+					principal, err = srv.ParseJWT(r.Context(), tokenString)
+				}
+			}
 
-					// Validate the auth token
-					if !srv.ValidateJWT(authToken) {
-						w.WriteHeader(http.StatusUnauthorized)
-						json.NewEncoder(w).Encode(&res)
-						return
-					}
-				} else {
-					if !srv.ValidateJWT(tokenString) {
-						w.WriteHeader(http.StatusUnauthorized)
-						json.NewEncoder(w).Encode(&res)
-						return
-					}
+			// User tokens are deleted when revoked, only accept those still stored
+			if err == nil && !isInternalRoute {
+				err = storefront.ErrTokenNotFound
+				if principal.TokenID != "" {
+					err = srv.AccessTokenExists(r.Context(), principal.TokenID)
 				}
 			}
 
-			next.ServeHTTP(w, r)
+			if err != nil {
+				code := utils.ErrorStatus(err)
+				msg := "Error validating and parsing token"
+
+				switch {
+				case errors.Is(err, utils.ErrEmptyHeader):
+					msg = "Empty authorization header"
+				case errors.Is(err, utils.ErrInvalidHeader):
+					msg = "Invalid authorization header"
+				case errors.Is(err, authentication.ErrInvalidToken):
+					msg = "Invalid authorization token"
+				case errors.Is(err, storefront.ErrTokenNotFound):
+					code = http.StatusUnauthorized
+					msg = "Revoked authorization token"
+				default:
+					log.Error().Msgf("[%s %s] Error occurred validating and parsing token, %s", r.Method, r.URL.Path, err.Error())
+				}
+
+				response.Error(w, r, code, msg)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(authentication.NewContext(r.Context(), principal)))
 		})
 	}
 }
@@ -8,6 +8,9 @@ import (
 	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/routes"
 
 	mockserver "github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver/mock"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	mockstore "github.com/anthonydip/flutter-messenger-go/internal/storefront/mock"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
 	mockauth "github.com/anthonydip/flutter-messenger-go/pkg/authentication/mock"
 
 	"github.com/gorilla/mux"
@@ -17,26 +20,45 @@ func TestAuthentication(t *testing.T) {
 	t.Parallel()
 
 	tests := map[string]struct {
-		authResult     mockauth.Result
-		expectedStatus int
+		authHeader       string
+		authResult       mockauth.Result
+		storefrontResult mockstore.Result
+		expectedStatus   int
 	}{
 		"Auth Passes": {
+			authHeader:     "Bearer some-access-token",
 			expectedStatus: http.StatusOK,
 		},
 		"Auth Fails": {
-			authResult:     mockauth.ValidateJWTFail(),
+			authHeader:     "Bearer some-access-token",
+			authResult:     mockauth.ParseJWTFail(),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"Revoked": {
+			authHeader:       "Bearer some-access-token",
+			storefrontResult: mockstore.AccessTokenExistsResult(storefront.ErrTokenNotFound),
+			expectedStatus:   http.StatusUnauthorized,
+		},
+		"No Header": {
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"Invalid Header": {
+			authHeader:     "some-access-token",
 			expectedStatus: http.StatusUnauthorized,
 		},
 	}
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			srv := mockserver.New().WithAuthentication(test.authResult)
+			srv := mockserver.New().WithAuthentication(test.authResult).WithStorefront(test.storefrontResult)
 
 			req, err := http.NewRequest(http.MethodGet, "/ping", nil)
 			if err != nil {
 				t.Fatalf("test failed while creating new HTTP request, %s", err.Error())
 			}
+			if test.authHeader != "" {
+				req.Header.Set("Authorization", test.authHeader)
+			}
 
 			r := mux.NewRouter()
 			r.Use(Authentication(srv))
@@ -52,3 +74,57 @@ func TestAuthentication(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthenticationPrincipal(t *testing.T) {
+	t.Parallel()
+
+	srv := mockserver.New()
+
+	req, err := http.NewRequest(http.MethodGet, "/users/friends", nil)
+	if err != nil {
+		t.Fatalf("test failed while creating new HTTP request, %s", err.Error())
+	}
+	req.Header.Set("Authorization", "Bearer some-access-token")
+
+	var principal authentication.Principal
+	var ok bool
+
+	r := mux.NewRouter()
+	r.Use(Authentication(srv))
+	r.HandleFunc("/users/friends", func(w http.ResponseWriter, r *http.Request) {
+		principal, ok = authentication.FromContext(r.Context())
+	}).Methods(http.MethodGet)
+
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !ok {
+		t.Fatal("expected the principal in the request context")
+	}
+	if principal.UserID != mockauth.Principal.UserID || principal.TokenType != authentication.TokenTypeUser {
+		t.Fatalf("expected principal %+v but got %+v", mockauth.Principal, principal)
+	}
+}
+
+func TestAuthenticationPublicRoute(t *testing.T) {
+	t.Parallel()
+
+	srv := mockserver.New().WithAuthentication(mockauth.ParseJWTFail())
+
+	req, err := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	if err != nil {
+		t.Fatalf("test failed while creating new HTTP request, %s", err.Error())
+	}
+
+	r := mux.NewRouter()
+	r.Use(Authentication(srv))
+	r.HandleFunc("/.well-known/jwks.json", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}).Methods(http.MethodGet)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected the public route to be served without a token but got %03d", rr.Code)
+	}
+}
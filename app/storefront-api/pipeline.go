@@ -4,13 +4,23 @@ import (
 	"net/http"
 
 	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/middleware"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
 	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/routes"
 	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/routes/auth/signin"
 	accessToken "github.com/anthonydip/flutter-messenger-go/app/storefront-api/routes/auth/tokens/access"
+	refreshToken "github.com/anthonydip/flutter-messenger-go/app/storefront-api/routes/auth/tokens/refresh"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/routes/conversations/messages"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/routes/groups"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/routes/groups/members"
 	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/routes/users"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/routes/users/blocked"
 	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/routes/users/friends"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/routes/users/friends/presence"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/routes/users/friends/requests"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/routes/wellknown"
 	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver"
 	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/ws"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
 	"github.com/gorilla/mux"
 	"github.com/rs/zerolog/log"
 )
@@ -22,19 +32,45 @@ func BuildPipeline(srv webserver.Server, hub *ws.Hub, r *mux.Router) {
 
 	r.Use(middleware.Authentication(srv))
 
+	r.HandleFunc("/.well-known/jwks.json", wellknown.JWKS(srv)).Methods(http.MethodGet)
+
 	r.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
-		user, _ := srv.ValidateParseJWT(r.URL.Query().Get("token"))
+		principal, ok := authentication.FromContext(r.Context())
+		if !ok {
+			log.Error().Msg("[GET /ws] Request was not authenticated")
+			response.Error(w, r, http.StatusUnauthorized, "Invalid authorization token")
+			return
+		}
 
-		ws.ServeWs(hub, w, r, user.Id)
+		ws.ServeWs(hub, w, r, principal.UserID, principal.TokenID)
 	})
 
 	r.HandleFunc("/auth/signin", signin.Post(srv)).Methods(http.MethodPost)
 
 	r.HandleFunc("/auth/tokens/access", accessToken.Post(srv)).Methods(http.MethodPost)
-	r.HandleFunc("/auth/tokens/access/{token}", accessToken.Delete(srv)).Methods(http.MethodDelete)
+	r.HandleFunc("/auth/tokens/access/{token}", accessToken.Delete(srv, hub)).Methods(http.MethodDelete)
+	r.HandleFunc("/auth/tokens/refresh", refreshToken.Post(srv, hub)).Methods(http.MethodPost)
 
 	r.HandleFunc("/users/{id:(?:[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[^@]+@[^/]+)}", users.Get(srv)).Methods(http.MethodGet)
 	r.HandleFunc("/users", users.Post(srv)).Methods(http.MethodPost)
-	r.HandleFunc("/users/friends", friends.Post(srv)).Methods(http.MethodPost)
 	r.HandleFunc("/users/friends", friends.Get(srv)).Methods(http.MethodGet)
+	r.HandleFunc("/users/friends/{id}", friends.Delete(srv, hub)).Methods(http.MethodDelete)
+	r.HandleFunc("/users/friends/presence", presence.Get(srv, hub)).Methods(http.MethodGet)
+	r.HandleFunc("/users/friends/requests", requests.Post(srv, hub)).Methods(http.MethodPost)
+	r.HandleFunc("/users/friends/requests", requests.Get(srv)).Methods(http.MethodGet)
+	r.HandleFunc("/users/friends/requests/{id}", requests.Patch(srv, hub)).Methods(http.MethodPatch)
+	r.HandleFunc("/users/friends/requests/{id}", requests.Delete(srv, hub)).Methods(http.MethodDelete)
+
+	r.HandleFunc("/users/blocked", blocked.Post(srv, hub)).Methods(http.MethodPost)
+	r.HandleFunc("/users/blocked", blocked.Get(srv)).Methods(http.MethodGet)
+	r.HandleFunc("/users/blocked/{id}", blocked.Delete(srv, hub)).Methods(http.MethodDelete)
+
+	r.HandleFunc("/conversations/{peerId}/messages", messages.Get(srv)).Methods(http.MethodGet)
+
+	r.HandleFunc("/groups", groups.Post(srv, hub)).Methods(http.MethodPost)
+	r.HandleFunc("/groups", groups.GetAll(srv)).Methods(http.MethodGet)
+	r.HandleFunc("/groups/{id}", groups.Get(srv)).Methods(http.MethodGet)
+	r.HandleFunc("/groups/{id}", groups.Patch(srv)).Methods(http.MethodPatch)
+	r.HandleFunc("/groups/{id}/members", members.Post(srv, hub)).Methods(http.MethodPost)
+	r.HandleFunc("/groups/{id}/members/{memberId}", members.Delete(srv)).Methods(http.MethodDelete)
 }
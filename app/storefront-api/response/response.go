@@ -0,0 +1,132 @@
+package response
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Media types of the responses of the API
+const (
+	MediaTypeJSON    = "application/json"
+	MediaTypeProblem = "application/problem+json"
+)
+
+// Data of a successful response, written next to the fields of the envelope
+type Data map[string]any
+
+// FieldError is a field of a request which failed validation, so that the
+// client can point at the failing input
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Envelope wrapping every JSON response of the API
+type Envelope struct {
+	Status        string       `json:"status"`
+	StatusCode    int          `json:"statusCode"`
+	StatusMessage string       `json:"statusMessage,omitempty"`
+	Errors        []FieldError `json:"errors,omitempty"`
+}
+
+// Problem is an RFC 7807 problem details object, written instead of the
+// envelope for errors when the client accepts application/problem+json
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// Status of an envelope with an HTTP status code, e.g. "NOT FOUND"
+func StatusText(code int) string {
+	if code == http.StatusOK {
+		return "SUCCESS"
+	}
+
+	return strings.ToUpper(http.StatusText(code))
+}
+
+// Write a successful response, with its data inlined in the envelope
+func Write(w http.ResponseWriter, code int, message string, data Data) {
+	res := Data{
+		"status":     StatusText(code),
+		"statusCode": code,
+	}
+	if message != "" {
+		res["statusMessage"] = message
+	}
+	for key, value := range data {
+		res[key] = value
+	}
+
+	w.Header().Set("Content-Type", MediaTypeJSON)
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(&res)
+}
+
+// Write an error response, as problem details if the client prefers them and
+// in the envelope otherwise. Fields lists the inputs which failed validation.
+func Error(w http.ResponseWriter, r *http.Request, code int, message string, fields ...FieldError) {
+	if !prefersProblem(r) {
+		res := Envelope{
+			Status:        StatusText(code),
+			StatusCode:    code,
+			StatusMessage: message,
+			Errors:        fields,
+		}
+
+		w.Header().Set("Content-Type", MediaTypeJSON)
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(&res)
+		return
+	}
+
+	res := Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(code),
+		Status:   code,
+		Detail:   message,
+		Instance: r.URL.Path,
+		Errors:   fields,
+	}
+
+	w.Header().Set("Content-Type", MediaTypeProblem)
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(&res)
+}
+
+// Whether the Accept header of a request ranks problem details at least as
+// high as plain JSON, the envelope being the default
+func prefersProblem(r *http.Request) bool {
+	problem, plain := 0.0, 0.0
+
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(accepted)
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+		}
+
+		switch mediaType {
+		case MediaTypeProblem:
+			problem = max(problem, q)
+		case MediaTypeJSON:
+			plain = max(plain, q)
+		}
+	}
+
+	return problem > 0 && problem >= plain
+}
@@ -0,0 +1,115 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		accept              string
+		expectedContentType string
+	}{
+		"No Accept": {
+			expectedContentType: MediaTypeJSON,
+		},
+		"Any": {
+			accept:              "*/*",
+			expectedContentType: MediaTypeJSON,
+		},
+		"Problem": {
+			accept:              "application/problem+json",
+			expectedContentType: MediaTypeProblem,
+		},
+		"Problem Preferred": {
+			accept:              "application/json;q=0.5, application/problem+json",
+			expectedContentType: MediaTypeProblem,
+		},
+		"JSON Preferred": {
+			accept:              "application/json, application/problem+json;q=0.5",
+			expectedContentType: MediaTypeJSON,
+		},
+		"Problem Refused": {
+			accept:              "application/problem+json;q=0",
+			expectedContentType: MediaTypeJSON,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodPost, "/users", nil)
+			if test.accept != "" {
+				req.Header.Set("Accept", test.accept)
+			}
+
+			rr := httptest.NewRecorder()
+			Error(rr, req, http.StatusBadRequest, "Invalid email", FieldError{Field: "email", Message: "Invalid email"})
+
+			if rr.Code != http.StatusBadRequest {
+				t.Fatalf("expected status code 400 but got %03d", rr.Code)
+			}
+
+			contentType := rr.Header().Get("Content-Type")
+			if contentType != test.expectedContentType {
+				t.Fatalf("expected content type %s but got %s", test.expectedContentType, contentType)
+			}
+
+			var errs []FieldError
+			if contentType == MediaTypeProblem {
+				var res Problem
+				if err := json.NewDecoder(rr.Body).Decode(&res); err != nil {
+					t.Fatalf("couldn't decode problem: %s", err.Error())
+				}
+				if res.Status != http.StatusBadRequest || res.Title != "Bad Request" || res.Detail != "Invalid email" || res.Instance != "/users" {
+					t.Fatalf("unexpected problem %+v", res)
+				}
+				errs = res.Errors
+			} else {
+				var res Envelope
+				if err := json.NewDecoder(rr.Body).Decode(&res); err != nil {
+					t.Fatalf("couldn't decode envelope: %s", err.Error())
+				}
+				if res.StatusCode != http.StatusBadRequest || res.Status != "BAD REQUEST" || res.StatusMessage != "Invalid email" {
+					t.Fatalf("unexpected envelope %+v", res)
+				}
+				errs = res.Errors
+			}
+
+			if len(errs) != 1 || errs[0].Field != "email" {
+				t.Fatalf("expected the email field to fail but got %+v", errs)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	rr := httptest.NewRecorder()
+	Write(rr, http.StatusOK, "User exists", Data{"user": map[string]string{"id": "some-id"}})
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status code 200 but got %03d", rr.Code)
+	}
+
+	var res struct {
+		Envelope
+		User struct {
+			Id string `json:"id"`
+		} `json:"user"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&res); err != nil {
+		t.Fatalf("couldn't decode envelope: %s", err.Error())
+	}
+
+	if res.Status != "SUCCESS" || res.StatusCode != http.StatusOK || res.StatusMessage != "User exists" || res.User.Id != "some-id" {
+		t.Fatalf("unexpected envelope %+v", res)
+	}
+}
@@ -2,21 +2,19 @@ package signin
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
 	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/utils"
 	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
 	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 )
 
-type Response struct {
-	Status        string `json:"status"`
-	StatusCode    int    `json:"statusCode"`
-	StatusMessage string `json:"statusMessage,omitempty"`
-	Token         string `json:"token,omitempty"`
-}
-
 // Sign a user in using email and password
 func Post(srv webserver.Server) http.HandlerFunc {
 	if srv == nil {
@@ -24,8 +22,6 @@ func Post(srv webserver.Server) http.HandlerFunc {
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-
 		user := dtos.User{}
 
 		r.Body = http.MaxBytesReader(w, r.Body, 1048576)
@@ -37,15 +33,7 @@ func Post(srv webserver.Server) http.HandlerFunc {
 		if err != nil {
 			log.Error().Msg("[POST /auth/signin] Unable to decode user")
 
-			w.WriteHeader(http.StatusBadRequest)
-
-			res := Response{
-				Status:        "BAD REQUEST",
-				StatusCode:    400,
-				StatusMessage: "Invalid request body",
-			}
-
-			json.NewEncoder(w).Encode(&res)
+			response.Error(w, r, http.StatusBadRequest, "Invalid request body")
 			return
 		}
 
@@ -56,157 +44,130 @@ func Post(srv webserver.Server) http.HandlerFunc {
 		// Validate user sign in request
 		err = utils.ValidatePostUser(user)
 		if err != nil {
-			res := Response{
-				Status:     "BAD REQUEST",
-				StatusCode: 400,
-			}
-			w.WriteHeader(http.StatusBadRequest)
+			code := utils.ErrorStatus(err)
+			msg := "Error validating user request"
 
-			switch err.Error() {
-			case "invalid email":
+			switch {
+			case errors.Is(err, utils.ErrInvalidEmail):
 				sublogger.Error().Msgf("[POST /auth/signin] Invalid email, received %s", user.Email)
-				res.StatusMessage = "Invalid email, criteria not met"
-			case "invalid provider":
+				msg = "Invalid email, criteria not met"
+			case errors.Is(err, utils.ErrInvalidProvider):
 				sublogger.Error().Msgf("[POST /auth/signin] Invalid provider, received %s", user.Provider)
-				res.StatusMessage = "Invalid provider, criteria not met"
-			case "invalid password":
+				msg = "Invalid provider, criteria not met"
+			case errors.Is(err, utils.ErrInvalidPassword):
 				sublogger.Error().Msg("[POST /auth/signin] Invalid password")
-				res.StatusMessage = "Invalid password, criteria not met"
+				msg = "Invalid password, criteria not met"
 			default:
 				sublogger.Error().Msgf("[POST /auth/signin] Error occurred validating user, %v", err)
-				res = Response{
-					Status:        "INTERNAL SERVER ERROR",
-					StatusCode:    500,
-					StatusMessage: "Error validating user request",
-				}
-				w.WriteHeader(http.StatusInternalServerError)
 			}
 
-			json.NewEncoder(w).Encode(&res)
+			response.Error(w, r, code, msg, utils.FieldErrors(err)...)
 			return
 		}
 
 		// Attempt to sign the user in by validating password with hash
-		err = srv.SignIn(user)
+		err = srv.SignIn(r.Context(), user)
 		if err != nil {
-			res := Response{
-				Status:     "UNAUTHORIZED",
-				StatusCode: 401,
-			}
+			code := utils.ErrorStatus(err)
+			msg := "Error validating user request"
 
-			switch err.Error() {
-			case "user does not exist":
+			switch {
+			case errors.Is(err, storefront.ErrUserNotFound):
 				sublogger.Error().Msg("[POST /auth/signin] User does not exist")
-				res = Response{
-					Status:        "NOT FOUND",
-					StatusCode:    404,
-					StatusMessage: "User does not exist",
-				}
-				w.WriteHeader(http.StatusNotFound)
-			case "invalid password":
+				msg = "User does not exist"
+			case errors.Is(err, storefront.ErrInvalidPassword):
 				sublogger.Error().Msg("[POST /auth/signin] Password does not match hash")
-				res.StatusMessage = "Incorrect password"
-				w.WriteHeader(http.StatusUnauthorized)
+				msg = "Incorrect password"
 			default:
 				sublogger.Error().Msgf("[POST /auth/signin] Error occurred signing user in, %v", err)
-				res = Response{
-					Status:        "INTERNAL SERVER ERROR",
-					StatusCode:    500,
-					StatusMessage: "Error validating user request",
-				}
-				w.WriteHeader(http.StatusInternalServerError)
 			}
 
-			json.NewEncoder(w).Encode(&res)
+			response.Error(w, r, code, msg)
 			return
 		}
 
 		sublogger.Info().Msgf("[POST /auth/signin] Validated user password")
 
 		// Get the full user info
-		userInfo, err := srv.GetUserByEmail(user.Email)
+		userInfo, err := srv.GetUserByEmail(r.Context(), user.Email)
 		if err != nil {
-			if err.Error() == "user does not exist" {
+			code := utils.ErrorStatus(err)
+			msg := "Error retrieving user information"
+
+			if errors.Is(err, storefront.ErrUserNotFound) {
 				sublogger.Error().Msgf("[POST /auth/signin] User information does not exist")
-				res := Response{
-					Status:        "NOT FOUND",
-					StatusCode:    404,
-					StatusMessage: "User does not exist",
-				}
-
-				w.WriteHeader(http.StatusNotFound)
-				json.NewEncoder(w).Encode(&res)
-				return
+				msg = "User does not exist"
 			} else {
 				sublogger.Error().Msgf("[POST /auth/signin] Error retrieving user information")
-				res := Response{
-					Status:        "INTERNAL SERVER ERROR",
-					StatusCode:    500,
-					StatusMessage: "Error retrieving user information",
-				}
-
-				w.WriteHeader(http.StatusInternalServerError)
-				json.NewEncoder(w).Encode(&res)
-				return
 			}
+
+			response.Error(w, r, code, msg)
+			return
 		}
 
 		sublogger.Info().Msgf("[POST /auth/signin] Retrieved user information from database")
 
 		// Generate access token for the user
-		token, err := srv.GenerateAccessToken(userInfo)
+		token, err := srv.GenerateAccessToken(r.Context(), userInfo)
 		if err != nil {
-			switch err.Error() {
-			case "error reading file":
-				sublogger.Error().Msg("[POST /auth/signin] Error reading private key")
-			case "error parsing pem":
-				sublogger.Error().Msg("[POST /auth/signin] Error parsing PEM file")
-			case "error signing token":
-				sublogger.Error().Msg("[POST /auth/signin] Error signing token")
-			default:
-				sublogger.Error().Msg("[POST /auth/signin] Unexpected error occurred generating user access token")
+			if errors.Is(err, authentication.ErrSigningToken) {
+				sublogger.Error().Msgf("[POST /auth/signin] Error signing token, %s", err.Error())
+			} else {
+				sublogger.Error().Msgf("[POST /auth/signin] Unexpected error occurred generating user access token, %s", err.Error())
 			}
 
-			res := Response{
-				Status:        "INTERNAL SERVER ERROR",
-				StatusCode:    500,
-				StatusMessage: "Error generating access token",
-			}
+			response.Error(w, r, http.StatusInternalServerError, "Error generating access token")
+			return
+		}
+		sublogger.Info().Msg("[POST /auth/signin] Successfully generated user access token")
 
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(&res)
+		// Add the access token to the database, by the ID it is revoked by, along
+		// with the refresh token family started with it so that signing out
+		// revokes both
+		familyID := uuid.New().String()
+
+		tokenID, err := srv.TokenID(r.Context(), token)
+		if err != nil {
+			sublogger.Error().Msg("[POST /auth/signin] Error reading access token ID")
+
+			response.Error(w, r, http.StatusInternalServerError, "Error generating access token")
 			return
 		}
-		sublogger.Info().Msgf("[POST /auth/signin] Successfully generated user access token %s", token)
 
-		// Add the access token to the database
-		err = srv.AddAccessToken(token, userInfo)
+		err = srv.AddAccessToken(r.Context(), tokenID, userInfo, familyID)
 		if err != nil {
 			log.Error().Msg("[POST /auth/signin] Error adding access token to the database")
 
-			res := Response{
-				Status:        "INTERNAL SERVER ERROR",
-				StatusCode:    500,
-				StatusMessage: "Error generating access token",
-			}
-
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(&res)
+			response.Error(w, r, http.StatusInternalServerError, "Error generating access token")
 			return
 		}
-		sublogger.Info().Msgf("[POST /auth/signin] Successfully added user access token %s to the database", token)
+		sublogger.Info().Msgf("[POST /auth/signin] Successfully added user access token %s to the database", tokenID)
 
-		sublogger.Info().Msgf("[POST /auth/signin] Successfully signed user in with access token %s", token)
+		// Issue a refresh token starting a new token family
+		refreshToken, expiresAt, err := srv.GenerateRefreshToken(r.Context())
+		if err != nil {
+			sublogger.Error().Msg("[POST /auth/signin] Error generating refresh token")
 
-		w.WriteHeader(http.StatusOK)
+			response.Error(w, r, http.StatusInternalServerError, "Error generating refresh token")
+			return
+		}
 
-		res := Response{
-			Status:        "SUCCESS",
-			StatusCode:    200,
-			StatusMessage: "Successfully signed user in",
-			Token:         token,
+		err = srv.AddRefreshToken(r.Context(), refreshToken, storefront.RefreshToken{
+			FamilyId:  familyID,
+			UserId:    userInfo.Id,
+			Email:     userInfo.Email,
+			Provider:  userInfo.Provider,
+			ExpiresAt: expiresAt.Unix(),
+		})
+		if err != nil {
+			sublogger.Error().Msgf("[POST /auth/signin] Error adding refresh token to the database, %s", err.Error())
+
+			response.Error(w, r, http.StatusInternalServerError, "Error generating refresh token")
+			return
 		}
 
-		json.NewEncoder(w).Encode(&res)
+		sublogger.Info().Msgf("[POST /auth/signin] Successfully signed user in with access token %s", tokenID)
+
+		response.Write(w, http.StatusOK, "Successfully signed user in", response.Data{"token": token, "refreshToken": refreshToken})
 	}
 }
@@ -2,12 +2,12 @@ package signin
 
 import (
 	"bytes"
-	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	mockserver "github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver/mock"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
 	mockstore "github.com/anthonydip/flutter-messenger-go/internal/storefront/mock"
 
 	"github.com/gorilla/mux"
@@ -25,11 +25,11 @@ func TestPost(t *testing.T) {
 		},
 		"conflict": {
 			expectedCode:     404,
-			storefrontResult: mockstore.SignInResult(errors.New("user does not exist")),
+			storefrontResult: mockstore.SignInResult(storefront.ErrUserNotFound),
 		},
 		"unauthorized": {
 			expectedCode:     401,
-			storefrontResult: mockstore.SignInResult(errors.New("invalid password")),
+			storefrontResult: mockstore.SignInResult(storefront.ErrInvalidPassword),
 		},
 	}
 
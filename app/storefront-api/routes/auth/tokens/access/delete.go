@@ -1,88 +1,87 @@
 package accessToken
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/utils"
 	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/ws"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
 	"github.com/gorilla/mux"
 	"github.com/rs/zerolog/log"
 )
 
-type DeleteResponse struct {
-	Status        string `json:"status"`
-	StatusCode    int    `json:"statusCode"`
-	StatusMessage string `json:"statusMessage,omitempty"`
-}
-
-// Delete a user access token
-func Delete(srv webserver.Server) http.HandlerFunc {
-	if srv == nil {
+// Delete a user access token, revoking it along with the refresh tokens issued
+// with it and closing the connections opened with it
+func Delete(srv webserver.Server, hub *ws.Hub) http.HandlerFunc {
+	if srv == nil || hub == nil {
 		log.Fatal().Msg("a nil dependency was passed to DELETE '/tokens/access/{token}'")
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-
 		// Get the token
 		params := mux.Vars(r)
 		token := strings.TrimSpace(params["token"])
 
-		sublogger := log.With().Any("token", token).Logger()
-		sublogger.Info().Msg("[DELETE /auth/tokens/{token}] Received a request")
+		log.Info().Msg("[DELETE /auth/tokens/{token}] Received a request")
 
-		// Check if the token exists
-		err := srv.AccessTokenExists(token)
+		// Tokens are stored by their ID, which is logged rather than the token
+		tokenID, err := srv.TokenID(r.Context(), token)
 		if err != nil {
-			if err.Error() == "token not found" {
-				sublogger.Error().Msg("[DELETE /auth/tokens/{token}] Token not found")
+			log.Error().Msg("[DELETE /auth/tokens/{token}] Invalid token")
 
-				res := DeleteResponse{
-					Status:        "NOT FOUND",
-					StatusCode:    404,
-					StatusMessage: "Token does not exist",
-				}
-				w.WriteHeader(http.StatusNotFound)
-				json.NewEncoder(w).Encode(&res)
-				return
+			response.Error(w, r, http.StatusNotFound, "Token does not exist")
+			return
+		}
+
+		sublogger := log.With().Str("tokenId", tokenID).Logger()
+
+		// Get the token, along with the refresh token family issued with it
+		info, err := srv.GetAccessToken(r.Context(), tokenID)
+		if err != nil {
+			code := utils.ErrorStatus(err)
+			msg := "Error checking for token"
+
+			if errors.Is(err, storefront.ErrTokenNotFound) {
+				sublogger.Error().Msg("[DELETE /auth/tokens/{token}] Token not found")
+				msg = "Token does not exist"
 			} else {
 				sublogger.Error().Msgf("[DELETE /auth/tokens/{token}] Error checking for token, %s", err.Error())
+			}
+
+			response.Error(w, r, code, msg)
+			return
+		}
+
+		// Revoke the refresh tokens first, so that the request can be retried
+		// while the access token is still there
+		if info.FamilyId != "" {
+			err = srv.RevokeRefreshTokens(r.Context(), info.FamilyId)
+			if err != nil {
+				sublogger.Error().Msgf("[DELETE /auth/tokens/{token}] Error revoking refresh tokens, %s", err.Error())
 
-				res := DeleteResponse{
-					Status:        "INTERNAL SERVER ERROR",
-					StatusCode:    500,
-					StatusMessage: "Error checking for token",
-				}
-				w.WriteHeader(http.StatusInternalServerError)
-				json.NewEncoder(w).Encode(&res)
+				response.Error(w, r, http.StatusInternalServerError, "Error deleting token")
 				return
 			}
 		}
 
 		// Delete the token from the database
-		err = srv.DeleteAccessToken(token)
+		err = srv.DeleteAccessToken(r.Context(), tokenID)
 		if err != nil {
 			sublogger.Error().Msgf("[DELETE /auth/tokens/{token}] Error deleting token from database, %s", err.Error())
 
-			res := DeleteResponse{
-				Status:        "INTERNAL SERVER ERROR",
-				StatusCode:    500,
-				StatusMessage: "Error deleting token",
-			}
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(&res)
+			response.Error(w, r, http.StatusInternalServerError, "Error deleting token")
 			return
 		}
 
+		// Close the connections opened with the token
+		hub.RevokeToken(tokenID)
+
 		sublogger.Info().Msg("[DELETE /auth/tokens/{token}] Token successfully deleted")
 
-		res := DeleteResponse{
-			Status:        "SUCCESS",
-			StatusCode:    200,
-			StatusMessage: "Token successfully deleted",
-		}
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(&res)
+		response.Write(w, http.StatusOK, "Token successfully deleted", nil)
 	}
 }
@@ -0,0 +1,65 @@
+package accessToken
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockserver "github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver/mock"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/ws"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	mockstore "github.com/anthonydip/flutter-messenger-go/internal/storefront/mock"
+
+	"github.com/gorilla/mux"
+)
+
+func TestDelete(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		expectedCode     int
+		storefrontResult mockstore.Result
+	}{
+		"deleted": {
+			expectedCode: 200,
+		},
+		"not found": {
+			expectedCode:     404,
+			storefrontResult: mockstore.GetAccessTokenResult(storefront.ErrTokenNotFound),
+		},
+		"refresh tokens not revoked": {
+			expectedCode:     500,
+			storefrontResult: mockstore.RevokeRefreshTokensResult(storefront.ErrInvalid),
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := mockserver.New().WithStorefront(test.storefrontResult)
+
+			hub, err := ws.NewHub(ws.Config{}, srv)
+			if err != nil {
+				t.Fatalf("couldn't create test hub: %s", err.Error())
+			}
+			go hub.Run()
+
+			r := mux.NewRouter()
+			r.HandleFunc("/auth/tokens/access/{token}", Delete(srv, hub)).Methods(http.MethodDelete)
+
+			req, err := http.NewRequest(http.MethodDelete, "/auth/tokens/access/some-access-token", nil)
+			if err != nil {
+				t.Fatalf("couldn't create test HTTP request: %s", err.Error())
+			}
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedCode {
+				t.Fatalf("expected status code %03d but got %03d (body: %s)", test.expectedCode, rr.Code, rr.Body)
+			}
+		})
+	}
+}
@@ -2,20 +2,19 @@ package accessToken
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/utils"
 	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
 	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 )
 
-type TokenResponse struct {
-	Status        string `json:"status"`
-	StatusCode    int    `json:"statusCode"`
-	StatusMessage string `json:"statusMessage,omitempty"`
-	Token         string `json:"token,omitempty"`
-}
-
 // Create a user access token
 func Post(srv webserver.Server) http.HandlerFunc {
 	if srv == nil {
@@ -23,8 +22,6 @@ func Post(srv webserver.Server) http.HandlerFunc {
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-
 		user := dtos.User{}
 
 		r.Body = http.MaxBytesReader(w, r.Body, 1048576)
@@ -36,88 +33,89 @@ func Post(srv webserver.Server) http.HandlerFunc {
 		if err != nil {
 			log.Error().Msg("[POST /tokens/access] Unable to decode user")
 
-			w.WriteHeader(http.StatusBadRequest)
-
-			res := TokenResponse{
-				Status:        "BAD REQUEST",
-				StatusCode:    400,
-				StatusMessage: "Invalid request body",
-			}
-
-			json.NewEncoder(w).Encode(&res)
+			response.Error(w, r, http.StatusBadRequest, "Invalid request body")
 			return
 		}
 
 		log.Info().Msgf("[POST /tokens/access] Received a request, %+v", user)
 
 		// Check if the user exists
-		user, err = srv.GetUserByEmail(user.Email)
+		user, err = srv.GetUserByEmail(r.Context(), user.Email)
 		if err != nil {
-			log.Info().Msgf("[POST /tokens/access] Specified user does not exist")
-
-			res := TokenResponse{
-				Status:        "NOT FOUND",
-				StatusCode:    404,
-				StatusMessage: "User does not exist",
+			code := utils.ErrorStatus(err)
+			msg := "Error retrieving user information"
+
+			if errors.Is(err, storefront.ErrUserNotFound) {
+				log.Info().Msgf("[POST /tokens/access] Specified user does not exist")
+				msg = "User does not exist"
+			} else {
+				log.Error().Msgf("[POST /tokens/access] Error retrieving user information, %s", err.Error())
 			}
 
-			w.WriteHeader(http.StatusNotFound)
-			json.NewEncoder(w).Encode(&res)
+			response.Error(w, r, code, msg)
 			return
 		}
 
 		// Generate user access token
-		token, err := srv.GenerateAccessToken(user)
+		token, err := srv.GenerateAccessToken(r.Context(), user)
 		if err != nil {
-			switch err.Error() {
-			case "error reading file":
-				log.Error().Msg("[POST /tokens/access] Error reading private key")
-			case "error parsing pem":
-				log.Error().Msg("[POST /tokens/access] Error parsing PEM file")
-			case "error signing token":
-				log.Error().Msg("[POST /tokens/access] Error signing token")
-			default:
-				log.Error().Msg("[POST /tokens/access] Unexpected error occurred generating user access token")
+			if errors.Is(err, authentication.ErrSigningToken) {
+				log.Error().Msgf("[POST /tokens/access] Error signing token, %s", err.Error())
+			} else {
+				log.Error().Msgf("[POST /tokens/access] Unexpected error occurred generating user access token, %s", err.Error())
 			}
 
-			res := TokenResponse{
-				Status:        "INTERNAL SERVER ERROR",
-				StatusCode:    500,
-				StatusMessage: "Error generating access token",
-			}
+			response.Error(w, r, http.StatusInternalServerError, "Error generating access token")
+			return
+		}
+
+		// Add the access token to the database, by the ID it is revoked by, along
+		// with the refresh token family started with it so that signing out
+		// revokes both
+		familyID := uuid.New().String()
 
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(&res)
+		tokenID, err := srv.TokenID(r.Context(), token)
+		if err != nil {
+			log.Error().Msg("[POST /tokens/access] Error reading access token ID")
+
+			response.Error(w, r, http.StatusInternalServerError, "Error generating access token")
 			return
 		}
 
-		// Add the access token to the database
-		err = srv.AddAccessToken(token, user)
+		err = srv.AddAccessToken(r.Context(), tokenID, user, familyID)
 		if err != nil {
 			log.Error().Msg("[POST /tokens/access] Error adding access token to the database")
 
-			res := TokenResponse{
-				Status:        "INTERNAL SERVER ERROR",
-				StatusCode:    500,
-				StatusMessage: "Error generating access token",
-			}
+			response.Error(w, r, http.StatusInternalServerError, "Error generating access token")
+			return
+		}
+		log.Info().Msgf("[POST /tokens/access] Successfully added access token to the database, %s", tokenID)
 
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(&res)
+		// Issue a refresh token starting a new token family
+		refreshToken, expiresAt, err := srv.GenerateRefreshToken(r.Context())
+		if err != nil {
+			log.Error().Msg("[POST /tokens/access] Error generating refresh token")
+
+			response.Error(w, r, http.StatusInternalServerError, "Error generating refresh token")
 			return
 		}
-		log.Info().Msgf("[POST /tokens/access] Successfully added access token to the database, %s", token)
 
-		res := TokenResponse{
-			Status:        "CREATED",
-			StatusCode:    201,
-			StatusMessage: "Successfully generated access token",
-			Token:         token,
+		err = srv.AddRefreshToken(r.Context(), refreshToken, storefront.RefreshToken{
+			FamilyId:  familyID,
+			UserId:    user.Id,
+			Email:     user.Email,
+			Provider:  user.Provider,
+			ExpiresAt: expiresAt.Unix(),
+		})
+		if err != nil {
+			log.Error().Msgf("[POST /tokens/access] Error adding refresh token to the database, %s", err.Error())
+
+			response.Error(w, r, http.StatusInternalServerError, "Error generating refresh token")
+			return
 		}
 
-		log.Info().Msgf("[POST /tokens/access] Successfully generated user access token, %s", token)
+		log.Info().Msgf("[POST /tokens/access] Successfully generated user access token, %s", tokenID)
 
-		w.WriteHeader(http.StatusCreated)
-		json.NewEncoder(w).Encode(&res)
+		response.Write(w, http.StatusCreated, "Successfully generated access token", response.Data{"token": token, "refreshToken": refreshToken})
 	}
 }
@@ -0,0 +1,145 @@
+package refreshToken
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/ws"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+	"github.com/rs/zerolog/log"
+)
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// Exchange a refresh token for a new access token, rotating the refresh token
+func Post(srv webserver.Server, hub *ws.Hub) http.HandlerFunc {
+	if srv == nil || hub == nil {
+		log.Fatal().Msg("a nil dependency was passed to POST '/auth/tokens/refresh'")
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := RefreshRequest{}
+
+		r.Body = http.MaxBytesReader(w, r.Body, 1048576)
+
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+
+		err := dec.Decode(&req)
+		if err != nil || req.RefreshToken == "" {
+			log.Error().Msg("[POST /auth/tokens/refresh] Unable to decode refresh token")
+
+			response.Error(w, r, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		log.Info().Msg("[POST /auth/tokens/refresh] Received a request")
+
+		// Mark the refresh token as used, a replayed token revokes its whole family
+		info, tokenIDs, err := srv.UseRefreshToken(r.Context(), req.RefreshToken)
+		if err != nil {
+			code := http.StatusUnauthorized
+			msg := "Invalid refresh token"
+
+			switch {
+			case errors.Is(err, storefront.ErrTokenNotFound):
+				log.Info().Msg("[POST /auth/tokens/refresh] Refresh token does not exist")
+			case errors.Is(err, storefront.ErrRefreshTokenReused):
+				log.Warn().Msgf("[POST /auth/tokens/refresh] Refresh token was reused, revoked its token family and %d access tokens", len(tokenIDs))
+				msg = "Refresh token was reused, please sign in again"
+
+				// Close the connections opened with the family's access tokens
+				for _, tokenID := range tokenIDs {
+					hub.RevokeToken(tokenID)
+				}
+			case errors.Is(err, storefront.ErrRefreshTokenRevoked):
+				log.Info().Msg("[POST /auth/tokens/refresh] Refresh token was revoked")
+				msg = "Refresh token was revoked, please sign in again"
+			case errors.Is(err, storefront.ErrRefreshTokenExpired):
+				log.Info().Msg("[POST /auth/tokens/refresh] Refresh token expired")
+				msg = "Refresh token expired, please sign in again"
+			default:
+				log.Error().Msgf("[POST /auth/tokens/refresh] Error using refresh token, %s", err.Error())
+				code = http.StatusInternalServerError
+				msg = "Error refreshing tokens"
+			}
+
+			response.Error(w, r, code, msg)
+			return
+		}
+
+		sublogger := log.With().Str("userId", info.UserId).Str("familyId", info.FamilyId).Logger()
+
+		user := dtos.User{
+			Id:       info.UserId,
+			Email:    info.Email,
+			Provider: info.Provider,
+		}
+
+		// Generate a new access token for the user
+		token, err := srv.GenerateAccessToken(r.Context(), user)
+		if err != nil {
+			if errors.Is(err, authentication.ErrSigningToken) {
+				sublogger.Error().Msgf("[POST /auth/tokens/refresh] Error signing token, %s", err.Error())
+			} else {
+				sublogger.Error().Msgf("[POST /auth/tokens/refresh] Unexpected error occurred generating user access token, %s", err.Error())
+			}
+
+			response.Error(w, r, http.StatusInternalServerError, "Error generating access token")
+			return
+		}
+
+		// Add the access token to the database, by the ID it is revoked by, in the
+		// family of the refresh token
+		tokenID, err := srv.TokenID(r.Context(), token)
+		if err != nil {
+			sublogger.Error().Msg("[POST /auth/tokens/refresh] Error reading access token ID")
+
+			response.Error(w, r, http.StatusInternalServerError, "Error generating access token")
+			return
+		}
+
+		err = srv.AddAccessToken(r.Context(), tokenID, user, info.FamilyId)
+		if err != nil {
+			sublogger.Error().Msgf("[POST /auth/tokens/refresh] Error adding access token to the database, %s", err.Error())
+
+			response.Error(w, r, http.StatusInternalServerError, "Error generating access token")
+			return
+		}
+
+		// Rotate the refresh token, keeping it in the same family. The family
+		// expires when its first token does, so rotating doesn't extend it.
+		refreshToken, _, err := srv.GenerateRefreshToken(r.Context())
+		if err != nil {
+			sublogger.Error().Msg("[POST /auth/tokens/refresh] Error generating refresh token")
+
+			response.Error(w, r, http.StatusInternalServerError, "Error generating refresh token")
+			return
+		}
+
+		err = srv.AddRefreshToken(r.Context(), refreshToken, storefront.RefreshToken{
+			FamilyId:  info.FamilyId,
+			UserId:    info.UserId,
+			Email:     info.Email,
+			Provider:  info.Provider,
+			ExpiresAt: info.ExpiresAt,
+		})
+		if err != nil {
+			sublogger.Error().Msgf("[POST /auth/tokens/refresh] Error adding refresh token to the database, %s", err.Error())
+
+			response.Error(w, r, http.StatusInternalServerError, "Error generating refresh token")
+			return
+		}
+
+		sublogger.Info().Msgf("[POST /auth/tokens/refresh] Successfully refreshed user access token, %s", tokenID)
+
+		response.Write(w, http.StatusOK, "Successfully refreshed tokens", response.Data{"token": token, "refreshToken": refreshToken})
+	}
+}
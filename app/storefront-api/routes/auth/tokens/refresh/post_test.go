@@ -0,0 +1,147 @@
+package refreshToken
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockserver "github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver/mock"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/ws"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	mockstore "github.com/anthonydip/flutter-messenger-go/internal/storefront/mock"
+
+	"github.com/gorilla/mux"
+)
+
+func TestPost(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		requestBody      string
+		expectedCode     int
+		storefrontResult mockstore.Result
+	}{
+		"success": {
+			requestBody:  `{"refreshToken": "some-refresh-token"}`,
+			expectedCode: 200,
+		},
+		"missing token": {
+			requestBody:  `{}`,
+			expectedCode: 400,
+		},
+		"not found": {
+			requestBody:      `{"refreshToken": "some-refresh-token"}`,
+			expectedCode:     401,
+			storefrontResult: mockstore.UseRefreshTokenResult(storefront.ErrTokenNotFound),
+		},
+		"reused": {
+			requestBody:      `{"refreshToken": "some-refresh-token"}`,
+			expectedCode:     401,
+			storefrontResult: mockstore.UseRefreshTokenResult(storefront.ErrRefreshTokenReused),
+		},
+		"expired": {
+			requestBody:      `{"refreshToken": "some-refresh-token"}`,
+			expectedCode:     401,
+			storefrontResult: mockstore.UseRefreshTokenResult(storefront.ErrRefreshTokenExpired),
+		},
+		"rotation fails": {
+			requestBody:      `{"refreshToken": "some-refresh-token"}`,
+			expectedCode:     500,
+			storefrontResult: mockstore.AddRefreshTokenResult(storefront.ErrInvalid),
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := mockserver.New().WithStorefront(test.storefrontResult)
+
+			hub, err := ws.NewHub(ws.Config{}, srv)
+			if err != nil {
+				t.Fatalf("couldn't create test hub: %s", err.Error())
+			}
+			go hub.Run()
+
+			r := mux.NewRouter()
+			r.HandleFunc("/auth/tokens/refresh", Post(srv, hub)).Methods(http.MethodPost)
+
+			req, err := http.NewRequest(http.MethodPost, "/auth/tokens/refresh", bytes.NewBufferString(test.requestBody))
+			if err != nil {
+				t.Fatalf("couldn't create test HTTP request: %s", err.Error())
+			}
+
+			req.Header.Add("Content-Type", "application/json")
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedCode {
+				t.Fatalf("expected status code %03d but got %03d (body: %s)", test.expectedCode, rr.Code, rr.Body)
+			}
+		})
+	}
+}
+
+func TestPostKeepsFamilyExpiry(t *testing.T) {
+	t.Parallel()
+
+	store := storefront.NewMemory()
+
+	srv := mockserver.New()
+	srv.Storefront = store
+
+	// A family signed into long ago, about to expire
+	expiresAt := time.Now().Add(time.Minute).Unix()
+	err := store.AddRefreshToken(context.Background(), "some-refresh-token", storefront.RefreshToken{
+		FamilyId:  "0f4c5e2a-9b7d-4c1e-8a3f-6d2b1e0c9a87",
+		UserId:    "8ae84a23-fa49-45eb-8000-bdc9b9fe074a",
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		t.Fatalf("couldn't add refresh token: %s", err.Error())
+	}
+
+	hub, err := ws.NewHub(ws.Config{}, srv)
+	if err != nil {
+		t.Fatalf("couldn't create test hub: %s", err.Error())
+	}
+	go hub.Run()
+
+	r := mux.NewRouter()
+	r.HandleFunc("/auth/tokens/refresh", Post(srv, hub)).Methods(http.MethodPost)
+
+	req, err := http.NewRequest(http.MethodPost, "/auth/tokens/refresh", bytes.NewBufferString(`{"refreshToken": "some-refresh-token"}`))
+	if err != nil {
+		t.Fatalf("couldn't create test HTTP request: %s", err.Error())
+	}
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != 200 {
+		t.Fatalf("expected status code 200 but got %03d (body: %s)", rr.Code, rr.Body)
+	}
+
+	res := struct {
+		RefreshToken string `json:"refreshToken"`
+	}{}
+	if err := json.NewDecoder(rr.Body).Decode(&res); err != nil {
+		t.Fatalf("couldn't decode response: %s", err.Error())
+	}
+
+	// The rotated token expires with its family rather than a TTL from now
+	info, _, err := store.UseRefreshToken(context.Background(), res.RefreshToken)
+	if err != nil {
+		t.Fatalf("couldn't use rotated refresh token: %s", err.Error())
+	}
+
+	if info.ExpiresAt != expiresAt {
+		t.Fatalf("expected the rotated token to expire at %d but got %d", expiresAt, info.ExpiresAt)
+	}
+}
@@ -0,0 +1,132 @@
+package messages
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 100
+)
+
+// Get the message history of a conversation
+func Get(srv webserver.Server) http.HandlerFunc {
+	if srv == nil {
+		log.Fatal().Msg("a nil dependency was passed to GET '/conversations/{peerId}/messages'")
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get the peerId
+		params := mux.Vars(r)
+		peerID := strings.TrimSpace(params["peerId"])
+
+		sublogger := log.With().Any("peerID", peerID).Logger()
+		sublogger.Info().Msg("[GET /conversations/{peerId}/messages] Received a request")
+
+		// Get the page size, defaulting if empty
+		limit := defaultLimit
+		if query := r.URL.Query().Get("limit"); query != "" {
+			n, err := strconv.Atoi(query)
+			if err != nil || n < 1 || n > maxLimit {
+				sublogger.Info().Msgf("[GET /conversations/{peerId}/messages] Invalid limit %s", query)
+				response.Error(w, r, http.StatusBadRequest, "Invalid limit in query parameter")
+				return
+			}
+			limit = n
+		}
+
+		// Decode the cursor of the previous page
+		before := ""
+		if query := r.URL.Query().Get("before"); query != "" {
+			id, err := base64.RawURLEncoding.DecodeString(query)
+			if err != nil {
+				sublogger.Info().Msgf("[GET /conversations/{peerId}/messages] Invalid cursor %s", query)
+				response.Error(w, r, http.StatusBadRequest, "Invalid cursor in query parameter")
+				return
+			}
+			before = string(id)
+		}
+
+		// Get the user authenticated by the middleware
+		principal, ok := authentication.FromContext(r.Context())
+		if !ok {
+			sublogger.Error().Msg("[GET /conversations/{peerId}/messages] Request was not authenticated")
+			response.Error(w, r, http.StatusUnauthorized, "Invalid authorization token")
+			return
+		}
+
+		sublogger = sublogger.With().Any("user", principal.UserID).Logger()
+
+		// Check the user is not requesting a conversation with themself
+		if principal.UserID == peerID {
+			sublogger.Info().Msg("[GET /conversations/{peerId}/messages] User requested a conversation with themself")
+			response.Error(w, r, http.StatusBadRequest, "Invalid peer")
+			return
+		}
+
+		// The conversation id is derived from the caller, so only their own conversations can be read
+		conversationID := storefront.ConversationID(principal.UserID, peerID)
+
+		// Check the peer exists, either as a user or as a group the user is a member of
+		_, err := srv.GetUser(r.Context(), peerID)
+		if err != nil {
+			if !errors.Is(err, storefront.ErrUserNotFound) {
+				sublogger.Error().Msgf("[GET /conversations/{peerId}/messages] Error getting peer from the database, %s", err.Error())
+				response.Error(w, r, http.StatusInternalServerError, "Error retrieving peer")
+				return
+			}
+
+			group, err := srv.GetGroup(r.Context(), peerID)
+			if err != nil && !errors.Is(err, storefront.ErrGroupNotFound) {
+				sublogger.Error().Msgf("[GET /conversations/{peerId}/messages] Error getting group from the database, %s", err.Error())
+				response.Error(w, r, http.StatusInternalServerError, "Error retrieving peer")
+				return
+			}
+
+			if _, ok := group.Members[principal.UserID]; !ok {
+				sublogger.Info().Msg("[GET /conversations/{peerId}/messages] Peer does not exist")
+				response.Error(w, r, http.StatusNotFound, "Peer does not exist")
+				return
+			}
+
+			conversationID = group.Id
+		}
+
+		messages, err := srv.GetMessages(r.Context(), conversationID, before, limit)
+		if err != nil {
+			// An unknown cursor is a bad request rather than a missing resource
+			if errors.Is(err, storefront.ErrMessageNotFound) {
+				sublogger.Info().Msg("[GET /conversations/{peerId}/messages] Cursor does not belong to the conversation")
+				response.Error(w, r, http.StatusBadRequest, "Invalid cursor in query parameter")
+				return
+			} else {
+				sublogger.Error().Msgf("[GET /conversations/{peerId}/messages] Error getting messages from the database, %s", err.Error())
+				response.Error(w, r, http.StatusInternalServerError, "Error retrieving messages")
+				return
+			}
+		}
+
+		sublogger.Info().Msgf("[GET /conversations/{peerId}/messages] Successfully retrieved %d messages", len(messages))
+
+		data := response.Data{"messages": messages}
+
+		// A full page may be followed by older messages
+		if len(messages) == limit {
+			data["cursor"] = base64.RawURLEncoding.EncodeToString([]byte(messages[len(messages)-1].Id))
+		}
+
+		response.Write(w, http.StatusOK, "Messages retrieved", data)
+	}
+}
@@ -0,0 +1,72 @@
+package messages
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockserver "github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver/mock"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	mockstore "github.com/anthonydip/flutter-messenger-go/internal/storefront/mock"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
+	mockauth "github.com/anthonydip/flutter-messenger-go/pkg/authentication/mock"
+
+	"github.com/gorilla/mux"
+)
+
+func TestGet(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		query             string
+		expectedCode      int
+		storefrontResults []mockstore.Result
+	}{
+		"found": {
+			expectedCode: 200,
+		},
+		"next page": {
+			query:        "?before=MGUzYjRhNGUtNWI3Yy00YzhmLTlkNjEtMGY2ZjVlNmQ3YTAx&limit=1",
+			expectedCode: 200,
+		},
+		"invalid limit": {
+			query:        "?limit=0",
+			expectedCode: 400,
+		},
+		"invalid cursor": {
+			query:             "?before=bm90LWEtbWVzc2FnZQ",
+			expectedCode:      400,
+			storefrontResults: []mockstore.Result{mockstore.GetMessagesResult(storefront.ErrMessageNotFound)},
+		},
+		"peer not found": {
+			expectedCode: 404,
+			storefrontResults: []mockstore.Result{
+				mockstore.GetUserResult(storefront.ErrUserNotFound),
+				mockstore.GetGroupResult(storefront.ErrGroupNotFound),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := mockserver.New().WithStorefront(test.storefrontResults...)
+
+			r := mux.NewRouter()
+			r.HandleFunc("/conversations/{peerId}/messages", Get(srv)).Methods(http.MethodGet)
+
+			req, err := http.NewRequest(http.MethodGet, "/conversations/1c5e2f3a-7d4b-4e8a-9f60-3b2a1d0c9e87/messages"+test.query, nil)
+			if err != nil {
+				t.Fatalf("couldn't create test HTTP request: %s", err.Error())
+			}
+
+			req = req.WithContext(authentication.NewContext(req.Context(), mockauth.Principal))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedCode {
+				t.Fatalf("expected status code %03d but got %03d (body: %s)", test.expectedCode, rr.Code, rr.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package groups
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// Get a group
+func Get(srv webserver.Server) http.HandlerFunc {
+	if srv == nil {
+		log.Fatal().Msg("a nil dependency was passed to GET '/groups/{id}'")
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get the groupID
+		params := mux.Vars(r)
+		groupID := strings.TrimSpace(params["id"])
+
+		sublogger := log.With().Any("groupID", groupID).Logger()
+		sublogger.Info().Msg("[GET /groups/{id}] Received a request")
+
+		// Get the user authenticated by the middleware
+		principal, ok := authentication.FromContext(r.Context())
+		if !ok {
+			sublogger.Error().Msg("[GET /groups/{id}] Request was not authenticated")
+			response.Error(w, r, http.StatusUnauthorized, "Invalid authorization token")
+			return
+		}
+
+		group, err := srv.GetGroup(r.Context(), groupID)
+		if err != nil && !errors.Is(err, storefront.ErrGroupNotFound) {
+			sublogger.Error().Msgf("[GET /groups/{id}] Error getting group from the database, %s", err.Error())
+			response.Error(w, r, http.StatusInternalServerError, "Error retrieving group")
+			return
+		}
+
+		// Groups the user is not a member of are treated as not existing
+		if _, ok := group.Members[principal.UserID]; !ok {
+			sublogger.Info().Msg("[GET /groups/{id}] Group does not exist")
+			response.Error(w, r, http.StatusNotFound, "Group does not exist")
+			return
+		}
+
+		sublogger.Info().Msgf("[GET /groups/{id}] Successfully retrieved group: %+v", group)
+
+		response.Write(w, http.StatusOK, "Group retrieved", response.Data{"group": &group})
+	}
+}
+
+// Get the groups of the user
+func GetAll(srv webserver.Server) http.HandlerFunc {
+	if srv == nil {
+		log.Fatal().Msg("a nil dependency was passed to GET '/groups'")
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Info().Msg("[GET /groups] Received a request")
+
+		// Get the user authenticated by the middleware
+		principal, ok := authentication.FromContext(r.Context())
+		if !ok {
+			log.Error().Msg("[GET /groups] Request was not authenticated")
+			response.Error(w, r, http.StatusUnauthorized, "Invalid authorization token")
+			return
+		}
+
+		sublogger := log.With().Any("user", principal.UserID).Logger()
+
+		groups, err := srv.GetGroups(r.Context(), principal.UserID)
+		if err != nil {
+			sublogger.Error().Msgf("[GET /groups] Error getting groups from the database, %s", err.Error())
+			response.Error(w, r, http.StatusInternalServerError, "Error retrieving user groups")
+			return
+		}
+
+		sublogger.Info().Msg("[GET /groups] Successfully retrieved user groups")
+
+		response.Write(w, http.StatusOK, "Groups retrieved", response.Data{"groups": groups})
+	}
+}
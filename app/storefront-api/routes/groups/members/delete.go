@@ -0,0 +1,107 @@
+package members
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// Remove a member from a group, or leave it when the member is the user
+func Delete(srv webserver.Server) http.HandlerFunc {
+	if srv == nil {
+		log.Fatal().Msg("a nil dependency was passed to DELETE '/groups/{id}/members/{memberId}'")
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get the groupID and memberID
+		params := mux.Vars(r)
+		groupID := strings.TrimSpace(params["id"])
+		memberID := strings.TrimSpace(params["memberId"])
+
+		sublogger := log.With().Any("groupID", groupID).Any("memberID", memberID).Logger()
+		sublogger.Info().Msg("[DELETE /groups/{id}/members/{memberId}] Received a request")
+
+		// Get the user authenticated by the middleware
+		principal, ok := authentication.FromContext(r.Context())
+		if !ok {
+			sublogger.Error().Msg("[DELETE /groups/{id}/members/{memberId}] Request was not authenticated")
+			response.Error(w, r, http.StatusUnauthorized, "Invalid authorization token")
+			return
+		}
+
+		group, err := srv.GetGroup(r.Context(), groupID)
+		if err != nil && !errors.Is(err, storefront.ErrGroupNotFound) {
+			sublogger.Error().Msgf("[DELETE /groups/{id}/members/{memberId}] Error getting group from the database, %s", err.Error())
+			response.Error(w, r, http.StatusInternalServerError, "Error retrieving group")
+			return
+		}
+
+		// Groups the user is not a member of are treated as not existing
+		role, ok := group.Members[principal.UserID]
+		if !ok {
+			sublogger.Info().Msg("[DELETE /groups/{id}/members/{memberId}] Group does not exist")
+			response.Error(w, r, http.StatusNotFound, "Group does not exist")
+			return
+		}
+
+		// Any member can leave, only admins can remove others
+		if memberID != principal.UserID && role != dtos.GroupAdmin {
+			sublogger.Info().Msg("[DELETE /groups/{id}/members/{memberId}] User is not an admin of the group")
+			response.Error(w, r, http.StatusForbidden, "Only group admins can remove other members")
+			return
+		}
+
+		if _, ok := group.Members[memberID]; !ok {
+			sublogger.Info().Msg("[DELETE /groups/{id}/members/{memberId}] Member does not exist")
+			response.Error(w, r, http.StatusNotFound, "Member does not exist")
+			return
+		}
+
+		err = srv.RemoveGroupMember(r.Context(), groupID, memberID)
+		if err != nil {
+			sublogger.Error().Msgf("[DELETE /groups/{id}/members/{memberId}] Error removing member, %s", err.Error())
+			response.Error(w, r, http.StatusInternalServerError, "Error removing member")
+			return
+		}
+
+		delete(group.Members, memberID)
+
+		// Delete the group once its last member is gone
+		if len(group.Members) == 0 {
+			err = srv.DeleteGroup(r.Context(), groupID)
+			if err != nil {
+				sublogger.Error().Msgf("[DELETE /groups/{id}/members/{memberId}] Error deleting empty group, %s", err.Error())
+			}
+		} else {
+			// Promote a member if the last admin left so the group can still be managed
+			remaining := make([]string, 0, len(group.Members))
+			hasAdmin := false
+			for id, role := range group.Members {
+				hasAdmin = hasAdmin || role == dtos.GroupAdmin
+				remaining = append(remaining, id)
+			}
+
+			if !hasAdmin {
+				sort.Strings(remaining)
+				err = srv.SetGroupMember(r.Context(), groupID, remaining[0], dtos.GroupAdmin)
+				if err != nil {
+					sublogger.Error().Msgf("[DELETE /groups/{id}/members/{memberId}] Error promoting new admin, %s", err.Error())
+				}
+			}
+		}
+
+		sublogger.Info().Msg("[DELETE /groups/{id}/members/{memberId}] Successfully removed member")
+
+		response.Write(w, http.StatusOK, "Member successfully removed", nil)
+	}
+}
@@ -0,0 +1,58 @@
+package members
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockserver "github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver/mock"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
+	mockauth "github.com/anthonydip/flutter-messenger-go/pkg/authentication/mock"
+
+	"github.com/gorilla/mux"
+)
+
+func TestDelete(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		memberID     string
+		expectedCode int
+	}{
+		"removed": {
+			memberID:     "alice",
+			expectedCode: 200,
+		},
+		"left": {
+			memberID:     "8ae84a23-fa49-45eb-8000-bdc9b9fe074a",
+			expectedCode: 200,
+		},
+		"not a member": {
+			memberID:     "carol",
+			expectedCode: 404,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := mockserver.New()
+
+			r := mux.NewRouter()
+			r.HandleFunc("/groups/{id}/members/{memberId}", Delete(srv)).Methods(http.MethodDelete)
+
+			req, err := http.NewRequest(http.MethodDelete, "/groups/5f0d7c1e-2b8a-4f3e-9c6d-7a1b2c3d4e5f/members/"+test.memberID, nil)
+			if err != nil {
+				t.Fatalf("couldn't create test HTTP request: %s", err.Error())
+			}
+
+			req = req.WithContext(authentication.NewContext(req.Context(), mockauth.Principal))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedCode {
+				t.Fatalf("expected status code %03d but got %03d (body: %s)", test.expectedCode, rr.Code, rr.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package members
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/utils"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/ws"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+type MemberRequest struct {
+	Id   string `json:"id,omitempty"`
+	Role string `json:"role,omitempty"`
+}
+
+// Add a member to a group
+func Post(srv webserver.Server, hub *ws.Hub) http.HandlerFunc {
+	if srv == nil || hub == nil {
+		log.Fatal().Msg("a nil dependency was passed to POST '/groups/{id}/members'")
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get the groupID
+		params := mux.Vars(r)
+		groupID := strings.TrimSpace(params["id"])
+
+		member := MemberRequest{}
+
+		r.Body = http.MaxBytesReader(w, r.Body, 1048576)
+
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+
+		err := dec.Decode(&member)
+		if err != nil {
+			log.Error().Msg("[POST /groups/{id}/members] Unable to decode member")
+
+			response.Error(w, r, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		sublogger := log.With().Any("groupID", groupID).Any("request", member).Logger()
+		sublogger.Info().Msg("[POST /groups/{id}/members] Received a request")
+
+		if strings.TrimSpace(member.Id) == "" {
+			sublogger.Error().Msg("[POST /groups/{id}/members] Empty member id provided")
+			response.Error(w, r, http.StatusBadRequest, "Invalid member")
+			return
+		}
+
+		// Members are added as regular members unless a role is given
+		if member.Role == "" {
+			member.Role = dtos.GroupMember
+		}
+
+		if member.Role != dtos.GroupAdmin && member.Role != dtos.GroupMember {
+			sublogger.Error().Msgf("[POST /groups/{id}/members] Invalid role, received %s", member.Role)
+			response.Error(w, r, http.StatusBadRequest, "Invalid role")
+			return
+		}
+
+		// Get the user authenticated by the middleware
+		principal, ok := authentication.FromContext(r.Context())
+		if !ok {
+			sublogger.Error().Msg("[POST /groups/{id}/members] Request was not authenticated")
+			response.Error(w, r, http.StatusUnauthorized, "Invalid authorization token")
+			return
+		}
+
+		group, err := srv.GetGroup(r.Context(), groupID)
+		if err != nil && !errors.Is(err, storefront.ErrGroupNotFound) {
+			sublogger.Error().Msgf("[POST /groups/{id}/members] Error getting group from the database, %s", err.Error())
+			response.Error(w, r, http.StatusInternalServerError, "Error retrieving group")
+			return
+		}
+
+		// Groups the user is not a member of are treated as not existing
+		role, ok := group.Members[principal.UserID]
+		if !ok {
+			sublogger.Info().Msg("[POST /groups/{id}/members] Group does not exist")
+			response.Error(w, r, http.StatusNotFound, "Group does not exist")
+			return
+		}
+
+		if role != dtos.GroupAdmin {
+			sublogger.Info().Msg("[POST /groups/{id}/members] User is not an admin of the group")
+			response.Error(w, r, http.StatusForbidden, "Only group admins can add members")
+			return
+		}
+
+		if _, ok := group.Members[member.Id]; ok {
+			sublogger.Info().Msg("[POST /groups/{id}/members] Member already added")
+			response.Error(w, r, http.StatusConflict, "Member already added")
+			return
+		}
+
+		// Check the new member exists
+		_, err = srv.GetUser(r.Context(), member.Id)
+		if err != nil {
+			code := utils.ErrorStatus(err)
+			msg := "Error retrieving member"
+
+			if errors.Is(err, storefront.ErrUserNotFound) {
+				sublogger.Info().Msg("[POST /groups/{id}/members] Member does not exist")
+				msg = "Member does not exist"
+			} else {
+				sublogger.Error().Msgf("[POST /groups/{id}/members] Error retrieving member from the database, %s", err.Error())
+			}
+
+			response.Error(w, r, code, msg)
+			return
+		}
+
+		// Only users the admin can message can be added, so that a group can't
+		// get around the messaging policy
+		allowed, err := hub.CanMessage(r.Context(), principal.UserID, member.Id)
+		if err != nil {
+			sublogger.Error().Msgf("[POST /groups/{id}/members] Error checking if member can be messaged, %s", err.Error())
+			response.Error(w, r, http.StatusInternalServerError, "Error retrieving member")
+			return
+		}
+
+		if !allowed {
+			sublogger.Info().Msg("[POST /groups/{id}/members] Member can't be messaged by the user")
+			response.Error(w, r, http.StatusForbidden, "Member can't be messaged by this user")
+			return
+		}
+
+		err = srv.SetGroupMember(r.Context(), groupID, member.Id, member.Role)
+		if err != nil {
+			sublogger.Error().Msgf("[POST /groups/{id}/members] Error adding member, %s", err.Error())
+			response.Error(w, r, http.StatusInternalServerError, "Error adding member")
+			return
+		}
+
+		group.Members[member.Id] = member.Role
+
+		sublogger.Info().Msg("[POST /groups/{id}/members] Successfully added member")
+
+		response.Write(w, http.StatusCreated, "Member successfully added", response.Data{"group": &group})
+	}
+}
@@ -0,0 +1,104 @@
+package groups
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/utils"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+type RenameRequest struct {
+	Name string `json:"name,omitempty"`
+}
+
+// Rename a group
+func Patch(srv webserver.Server) http.HandlerFunc {
+	if srv == nil {
+		log.Fatal().Msg("a nil dependency was passed to PATCH '/groups/{id}'")
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get the groupID
+		params := mux.Vars(r)
+		groupID := strings.TrimSpace(params["id"])
+
+		request := RenameRequest{}
+
+		r.Body = http.MaxBytesReader(w, r.Body, 1048576)
+
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+
+		err := dec.Decode(&request)
+		if err != nil {
+			log.Error().Msg("[PATCH /groups/{id}] Unable to decode group")
+
+			response.Error(w, r, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		sublogger := log.With().Any("groupID", groupID).Any("request", request).Logger()
+		sublogger.Info().Msg("[PATCH /groups/{id}] Received a request")
+
+		// Validate the group name
+		request.Name = strings.TrimSpace(request.Name)
+		err = utils.ValidateGroupName(request.Name)
+		if err != nil {
+			sublogger.Error().Msgf("[PATCH /groups/{id}] Invalid group name provided")
+			response.Error(w, r, http.StatusBadRequest, "Invalid group name")
+			return
+		}
+
+		// Get the user authenticated by the middleware
+		principal, ok := authentication.FromContext(r.Context())
+		if !ok {
+			sublogger.Error().Msg("[PATCH /groups/{id}] Request was not authenticated")
+			response.Error(w, r, http.StatusUnauthorized, "Invalid authorization token")
+			return
+		}
+
+		group, err := srv.GetGroup(r.Context(), groupID)
+		if err != nil && !errors.Is(err, storefront.ErrGroupNotFound) {
+			sublogger.Error().Msgf("[PATCH /groups/{id}] Error getting group from the database, %s", err.Error())
+			response.Error(w, r, http.StatusInternalServerError, "Error retrieving group")
+			return
+		}
+
+		// Groups the user is not a member of are treated as not existing
+		role, ok := group.Members[principal.UserID]
+		if !ok {
+			sublogger.Info().Msg("[PATCH /groups/{id}] Group does not exist")
+			response.Error(w, r, http.StatusNotFound, "Group does not exist")
+			return
+		}
+
+		if role != dtos.GroupAdmin {
+			sublogger.Info().Msg("[PATCH /groups/{id}] User is not an admin of the group")
+			response.Error(w, r, http.StatusForbidden, "Only group admins can rename the group")
+			return
+		}
+
+		err = srv.RenameGroup(r.Context(), groupID, request.Name)
+		if err != nil {
+			sublogger.Error().Msgf("[PATCH /groups/{id}] Error renaming group, %s", err.Error())
+			response.Error(w, r, http.StatusInternalServerError, "Error renaming group")
+			return
+		}
+
+		group.Name = request.Name
+
+		sublogger.Info().Msg("[PATCH /groups/{id}] Successfully renamed group")
+
+		response.Write(w, http.StatusOK, "Group successfully renamed", response.Data{"group": &group})
+	}
+}
@@ -0,0 +1,64 @@
+package groups
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockserver "github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver/mock"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	mockstore "github.com/anthonydip/flutter-messenger-go/internal/storefront/mock"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
+	mockauth "github.com/anthonydip/flutter-messenger-go/pkg/authentication/mock"
+
+	"github.com/gorilla/mux"
+)
+
+func TestPatch(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		requestBody      string
+		expectedCode     int
+		storefrontResult mockstore.Result
+	}{
+		"renamed": {
+			requestBody:  `{"name": "Weekend Plans"}`,
+			expectedCode: 200,
+		},
+		"invalid name": {
+			requestBody:  `{"name": "   "}`,
+			expectedCode: 400,
+		},
+		"not found": {
+			requestBody:      `{"name": "Weekend Plans"}`,
+			expectedCode:     404,
+			storefrontResult: mockstore.GetGroupResult(storefront.ErrGroupNotFound),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := mockserver.New().WithStorefront(test.storefrontResult)
+
+			r := mux.NewRouter()
+			r.HandleFunc("/groups/{id}", Patch(srv)).Methods(http.MethodPatch)
+
+			req, err := http.NewRequest(http.MethodPatch, "/groups/5f0d7c1e-2b8a-4f3e-9c6d-7a1b2c3d4e5f", bytes.NewBuffer([]byte(test.requestBody)))
+			if err != nil {
+				t.Fatalf("couldn't create test HTTP request: %s", err.Error())
+			}
+
+			req.Header.Add("Content-Type", "application/json")
+			req = req.WithContext(authentication.NewContext(req.Context(), mockauth.Principal))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedCode {
+				t.Fatalf("expected status code %03d but got %03d (body: %s)", test.expectedCode, rr.Code, rr.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package groups
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/utils"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/ws"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+
+	"github.com/rs/zerolog/log"
+)
+
+type GroupRequest struct {
+	Name    string   `json:"name,omitempty"`
+	Members []string `json:"members,omitempty"`
+}
+
+// Create a group
+func Post(srv webserver.Server, hub *ws.Hub) http.HandlerFunc {
+	if srv == nil || hub == nil {
+		log.Fatal().Msg("a nil dependency was passed to POST '/groups'")
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		request := GroupRequest{}
+
+		r.Body = http.MaxBytesReader(w, r.Body, 1048576)
+
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+
+		err := dec.Decode(&request)
+		if err != nil {
+			log.Error().Msg("[POST /groups] Unable to decode group")
+
+			response.Error(w, r, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		log.Info().Msgf("[POST /groups] Received a request: %+v", request)
+
+		sublogger := log.With().Any("request", request).Logger()
+
+		// Validate the group name
+		request.Name = strings.TrimSpace(request.Name)
+		err = utils.ValidateGroupName(request.Name)
+		if err != nil {
+			sublogger.Error().Msgf("[POST /groups] Invalid group name provided")
+			response.Error(w, r, http.StatusBadRequest, "Invalid group name")
+			return
+		}
+
+		// Get the user authenticated by the middleware
+		principal, ok := authentication.FromContext(r.Context())
+		if !ok {
+			sublogger.Error().Msg("[POST /groups] Request was not authenticated")
+			response.Error(w, r, http.StatusUnauthorized, "Invalid authorization token")
+			return
+		}
+
+		// The creator is the group's first admin
+		members := map[string]string{principal.UserID: dtos.GroupAdmin}
+
+		for _, memberID := range request.Members {
+			memberID = strings.TrimSpace(memberID)
+			if memberID == "" {
+				sublogger.Error().Msg("[POST /groups] Empty member id provided")
+				response.Error(w, r, http.StatusBadRequest, "Invalid member")
+				return
+			}
+
+			if _, ok := members[memberID]; ok {
+				continue
+			}
+
+			// Check the member exists
+			_, err := srv.GetUser(r.Context(), memberID)
+			if err != nil {
+				code := utils.ErrorStatus(err)
+				msg := "Error retrieving member"
+
+				if errors.Is(err, storefront.ErrUserNotFound) {
+					sublogger.Info().Msgf("[POST /groups] Member %s does not exist", memberID)
+					msg = "Member does not exist"
+				} else {
+					sublogger.Error().Msgf("[POST /groups] Error retrieving member from the database, %s", err.Error())
+				}
+
+				response.Error(w, r, code, msg)
+				return
+			}
+
+			// Only users the creator can message can be added, so that a group
+			// can't get around the messaging policy
+			allowed, err := hub.CanMessage(r.Context(), principal.UserID, memberID)
+			if err != nil {
+				sublogger.Error().Msgf("[POST /groups] Error checking if member %s can be messaged, %s", memberID, err.Error())
+				response.Error(w, r, http.StatusInternalServerError, "Error retrieving member")
+				return
+			}
+
+			if !allowed {
+				sublogger.Info().Msgf("[POST /groups] Member %s can't be messaged by the user", memberID)
+				response.Error(w, r, http.StatusForbidden, "Member can't be messaged by this user")
+				return
+			}
+
+			members[memberID] = dtos.GroupMember
+		}
+
+		group, err := srv.CreateGroup(r.Context(), dtos.Group{Name: request.Name, Members: members})
+		if err != nil {
+			sublogger.Error().Msgf("[POST /groups] Error creating group, %s", err.Error())
+			response.Error(w, r, http.StatusInternalServerError, "Error creating group")
+			return
+		}
+
+		sublogger.Info().Msgf("[POST /groups] Successfully created group: %+v", group)
+
+		response.Write(w, http.StatusCreated, "Group successfully created", response.Data{"group": &group})
+	}
+}
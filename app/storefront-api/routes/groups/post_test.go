@@ -0,0 +1,80 @@
+package groups
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockserver "github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver/mock"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/ws"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
+	mockauth "github.com/anthonydip/flutter-messenger-go/pkg/authentication/mock"
+
+	"github.com/gorilla/mux"
+)
+
+func TestPost(t *testing.T) {
+	t.Parallel()
+
+	// Every user of the storefront mock has the mock user as their only friend,
+	// so the member is a stranger who is a friend of a friend
+	tests := map[string]struct {
+		policy       string
+		requestBody  string
+		expectedCode int
+	}{
+		"created": {
+			policy:       ws.PolicyAnyone,
+			requestBody:  `{"name": "Climbing", "members": ["1c5e2f3a-7d4b-4e8a-9f60-3b2a1d0c9e87"]}`,
+			expectedCode: 201,
+		},
+		"friend of friend": {
+			policy:       ws.PolicyFriendsOfFriends,
+			requestBody:  `{"name": "Climbing", "members": ["1c5e2f3a-7d4b-4e8a-9f60-3b2a1d0c9e87"]}`,
+			expectedCode: 201,
+		},
+		"stranger": {
+			policy:       ws.PolicyFriends,
+			requestBody:  `{"name": "Climbing", "members": ["1c5e2f3a-7d4b-4e8a-9f60-3b2a1d0c9e87"]}`,
+			expectedCode: 403,
+		},
+		"invalid name": {
+			policy:       ws.PolicyAnyone,
+			requestBody:  `{"name": "", "members": ["1c5e2f3a-7d4b-4e8a-9f60-3b2a1d0c9e87"]}`,
+			expectedCode: 400,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := mockserver.New()
+
+			hub, err := ws.NewHub(ws.Config{MessagingPolicy: test.policy}, srv)
+			if err != nil {
+				t.Fatalf("couldn't create test hub: %s", err.Error())
+			}
+
+			r := mux.NewRouter()
+			r.HandleFunc("/groups", Post(srv, hub)).Methods(http.MethodPost)
+
+			req, err := http.NewRequest(http.MethodPost, "/groups", bytes.NewBufferString(test.requestBody))
+			if err != nil {
+				t.Fatalf("couldn't create test HTTP request: %s", err.Error())
+			}
+
+			req.Header.Add("Content-Type", "application/json")
+			req = req.WithContext(authentication.NewContext(req.Context(), mockauth.Principal))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedCode {
+				t.Fatalf("expected status code %03d but got %03d (body: %s)", test.expectedCode, rr.Code, rr.Body)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	"net/http"
 
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
 	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver"
 	"github.com/rs/zerolog/log"
 )
@@ -10,6 +11,7 @@ import (
 // Ping is for the Kubernetes liveness probe
 func Ping(srv webserver.Server) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", response.MediaTypeJSON)
 		w.WriteHeader(http.StatusOK)
 
 		if _, err := w.Write([]byte(`"PONG"`)); err != nil {
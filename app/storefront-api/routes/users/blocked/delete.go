@@ -0,0 +1,63 @@
+package blocked
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/utils"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/ws"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// Unblock a user
+func Delete(srv webserver.Server, hub *ws.Hub) http.HandlerFunc {
+	if srv == nil || hub == nil {
+		log.Fatal().Msg("a nil dependency was passed to DELETE '/users/blocked/{id}'")
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get the blockedID
+		params := mux.Vars(r)
+		blockedID := strings.TrimSpace(params["id"])
+
+		sublogger := log.With().Any("blockedID", blockedID).Logger()
+		sublogger.Info().Msg("[DELETE /users/blocked/{id}] Received a request")
+
+		// Get the user authenticated by the middleware
+		principal, ok := authentication.FromContext(r.Context())
+		if !ok {
+			sublogger.Error().Msg("[DELETE /users/blocked/{id}] Request was not authenticated")
+			response.Error(w, r, http.StatusUnauthorized, "Invalid authorization token")
+			return
+		}
+
+		err := srv.UnblockUser(r.Context(), principal.UserID, blockedID)
+		if err != nil {
+			code := utils.ErrorStatus(err)
+			msg := "Error unblocking user"
+
+			if errors.Is(err, storefront.ErrUserNotBlocked) {
+				sublogger.Info().Msg("[DELETE /users/blocked/{id}] User is not blocked")
+				msg = "User is not blocked"
+			} else {
+				sublogger.Error().Msgf("[DELETE /users/blocked/{id}] Error unblocking user, %s", err.Error())
+			}
+
+			response.Error(w, r, code, msg)
+			return
+		}
+
+		hub.Unblock(principal.UserID, blockedID)
+
+		sublogger.Info().Msg("[DELETE /users/blocked/{id}] Successfully unblocked user")
+
+		response.Write(w, http.StatusOK, "User successfully unblocked", nil)
+	}
+}
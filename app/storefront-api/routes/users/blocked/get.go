@@ -0,0 +1,41 @@
+package blocked
+
+import (
+	"net/http"
+
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Get the users the user has blocked
+func Get(srv webserver.Server) http.HandlerFunc {
+	if srv == nil {
+		log.Fatal().Msg("a nil dependency was passed to GET '/users/blocked'")
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Info().Msg("[GET /users/blocked] Received a request")
+
+		// Get the user authenticated by the middleware
+		principal, ok := authentication.FromContext(r.Context())
+		if !ok {
+			log.Error().Msg("[GET /users/blocked] Request was not authenticated")
+			response.Error(w, r, http.StatusUnauthorized, "Invalid authorization token")
+			return
+		}
+
+		blocked, err := srv.GetBlockedUsers(r.Context(), principal.UserID)
+		if err != nil {
+			log.Error().Msgf("[GET /users/blocked] Error getting blocked users from the database, %s", err.Error())
+			response.Error(w, r, http.StatusInternalServerError, "Error getting blocked users")
+			return
+		}
+
+		log.Info().Msgf("[GET /users/blocked] Successfully retrieved %d blocked users", len(blocked))
+
+		response.Write(w, http.StatusOK, "Blocked users retrieved", response.Data{"blocked": blocked})
+	}
+}
@@ -0,0 +1,119 @@
+package blocked
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/utils"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/ws"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+
+	"github.com/rs/zerolog/log"
+)
+
+type BlockRequest struct {
+	Id string `json:"id,omitempty"`
+}
+
+// Block a user
+func Post(srv webserver.Server, hub *ws.Hub) http.HandlerFunc {
+	if srv == nil || hub == nil {
+		log.Fatal().Msg("a nil dependency was passed to POST '/users/blocked'")
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		request := BlockRequest{}
+
+		r.Body = http.MaxBytesReader(w, r.Body, 1048576)
+
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+
+		err := dec.Decode(&request)
+		if err != nil {
+			log.Error().Msg("[POST /users/blocked] Unable to decode blocked user")
+
+			response.Error(w, r, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		log.Info().Msgf("[POST /users/blocked] Received a request: %+v", request)
+
+		sublogger := log.With().Any("request", request).Logger()
+
+		request.Id = strings.TrimSpace(request.Id)
+		if request.Id == "" {
+			sublogger.Error().Msg("[POST /users/blocked] Empty user id provided")
+			response.Error(w, r, http.StatusBadRequest, "Invalid user")
+			return
+		}
+
+		// Get the user authenticated by the middleware
+		principal, ok := authentication.FromContext(r.Context())
+		if !ok {
+			sublogger.Error().Msg("[POST /users/blocked] Request was not authenticated")
+			response.Error(w, r, http.StatusUnauthorized, "Invalid authorization token")
+			return
+		}
+
+		// Check if user is attempting to block themself
+		if principal.UserID == request.Id {
+			sublogger.Error().Msg("[POST /users/blocked] User attempted to block themself")
+			response.Error(w, r, http.StatusBadRequest, "Invalid user")
+			return
+		}
+
+		// Check the user exists
+		_, err = srv.GetUser(r.Context(), request.Id)
+		if err != nil {
+			code := utils.ErrorStatus(err)
+			msg := "Error retrieving user"
+
+			if errors.Is(err, storefront.ErrUserNotFound) {
+				sublogger.Info().Msg("[POST /users/blocked] User does not exist")
+				msg = "User does not exist"
+			} else {
+				sublogger.Error().Msgf("[POST /users/blocked] Error retrieving user from the database, %s", err.Error())
+			}
+
+			response.Error(w, r, code, msg)
+			return
+		}
+
+		blocked := dtos.BlockedUser{
+			Id:        request.Id,
+			Timestamp: time.Now().UnixMilli(),
+		}
+
+		err = srv.BlockUser(r.Context(), principal.UserID, blocked)
+		if err != nil {
+			code := utils.ErrorStatus(err)
+			msg := "Error blocking user"
+
+			if errors.Is(err, storefront.ErrUserNotFound) {
+				sublogger.Info().Msg("[POST /users/blocked] User does not exist")
+				msg = "User does not exist"
+			} else {
+				sublogger.Error().Msgf("[POST /users/blocked] Error blocking user, %s", err.Error())
+			}
+
+			response.Error(w, r, code, msg)
+			return
+		}
+
+		// Stop routing frames between the two users right away
+		hub.Block(principal.UserID, blocked.Id)
+		hub.InvalidateFriends(principal.UserID, blocked.Id)
+
+		sublogger.Info().Msg("[POST /users/blocked] Successfully blocked user")
+
+		response.Write(w, http.StatusCreated, "User successfully blocked", response.Data{"blocked": &blocked})
+	}
+}
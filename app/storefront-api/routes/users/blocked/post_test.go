@@ -0,0 +1,86 @@
+package blocked
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockserver "github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver/mock"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/ws"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	mockstore "github.com/anthonydip/flutter-messenger-go/internal/storefront/mock"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
+	mockauth "github.com/anthonydip/flutter-messenger-go/pkg/authentication/mock"
+
+	"github.com/gorilla/mux"
+)
+
+func TestPost(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		requestBody      string
+		expectedCode     int
+		storefrontResult mockstore.Result
+	}{
+		"blocked": {
+			requestBody:  `{"id": "1c5e2f3a-7d4b-4e8a-9f60-3b2a1d0c9e87"}`,
+			expectedCode: 201,
+		},
+		"yourself": {
+			requestBody:  `{"id": "8ae84a23-fa49-45eb-8000-bdc9b9fe074a"}`,
+			expectedCode: 400,
+		},
+		"empty id": {
+			requestBody:  `{"id": " "}`,
+			expectedCode: 400,
+		},
+		"user not found": {
+			requestBody:      `{"id": "1c5e2f3a-7d4b-4e8a-9f60-3b2a1d0c9e87"}`,
+			expectedCode:     404,
+			storefrontResult: mockstore.GetUserResult(storefront.ErrUserNotFound),
+		},
+		"user deleted while blocking": {
+			requestBody:      `{"id": "1c5e2f3a-7d4b-4e8a-9f60-3b2a1d0c9e87"}`,
+			expectedCode:     404,
+			storefrontResult: mockstore.BlockUserResult(storefront.ErrUserNotFound),
+		},
+		"blocking fails": {
+			requestBody:      `{"id": "1c5e2f3a-7d4b-4e8a-9f60-3b2a1d0c9e87"}`,
+			expectedCode:     500,
+			storefrontResult: mockstore.BlockUserResult(errors.New("unavailable")),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := mockserver.New().WithStorefront(test.storefrontResult)
+
+			hub, err := ws.NewHub(ws.Config{}, srv)
+			if err != nil {
+				t.Fatalf("couldn't create test hub: %s", err.Error())
+			}
+			go hub.Run()
+
+			r := mux.NewRouter()
+			r.HandleFunc("/users/blocked", Post(srv, hub)).Methods(http.MethodPost)
+
+			req, err := http.NewRequest(http.MethodPost, "/users/blocked", bytes.NewBuffer([]byte(test.requestBody)))
+			if err != nil {
+				t.Fatalf("couldn't create test HTTP request: %s", err.Error())
+			}
+
+			req.Header.Add("Content-Type", "application/json")
+			req = req.WithContext(authentication.NewContext(req.Context(), mockauth.Principal))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedCode {
+				t.Fatalf("expected status code %03d but got %03d (body: %s)", test.expectedCode, rr.Code, rr.Body)
+			}
+		})
+	}
+}
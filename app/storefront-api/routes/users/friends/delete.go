@@ -0,0 +1,63 @@
+package friends
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/utils"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/ws"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// Remove a friend, on both sides of the friendship
+func Delete(srv webserver.Server, hub *ws.Hub) http.HandlerFunc {
+	if srv == nil || hub == nil {
+		log.Fatal().Msg("a nil dependency was passed to DELETE '/users/friends/{id}'")
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get the friendID
+		params := mux.Vars(r)
+		friendID := strings.TrimSpace(params["id"])
+
+		sublogger := log.With().Any("friendID", friendID).Logger()
+		sublogger.Info().Msg("[DELETE /users/friends/{id}] Received a request")
+
+		// Get the user authenticated by the middleware
+		principal, ok := authentication.FromContext(r.Context())
+		if !ok {
+			sublogger.Error().Msg("[DELETE /users/friends/{id}] Request was not authenticated")
+			response.Error(w, r, http.StatusUnauthorized, "Invalid authorization token")
+			return
+		}
+
+		err := srv.DeleteFriend(r.Context(), principal.UserID, friendID)
+		if err != nil {
+			code := utils.ErrorStatus(err)
+			msg := "Error removing friend"
+
+			if errors.Is(err, storefront.ErrFriendNotFound) {
+				sublogger.Info().Msg("[DELETE /users/friends/{id}] Friend does not exist")
+				msg = "Friend does not exist"
+			} else {
+				sublogger.Error().Msgf("[DELETE /users/friends/{id}] Error removing friend, %s", err.Error())
+			}
+
+			response.Error(w, r, code, msg)
+			return
+		}
+
+		hub.InvalidateFriends(principal.UserID, friendID)
+
+		sublogger.Info().Msg("[DELETE /users/friends/{id}] Successfully removed friend")
+
+		response.Write(w, http.StatusOK, "Successfully removed friend", nil)
+	}
+}
@@ -0,0 +1,61 @@
+package friends
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockserver "github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver/mock"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/ws"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	mockstore "github.com/anthonydip/flutter-messenger-go/internal/storefront/mock"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
+	mockauth "github.com/anthonydip/flutter-messenger-go/pkg/authentication/mock"
+
+	"github.com/gorilla/mux"
+)
+
+func TestDelete(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		expectedCode     int
+		storefrontResult mockstore.Result
+	}{
+		"removed": {
+			expectedCode: 200,
+		},
+		"not a friend": {
+			expectedCode:     404,
+			storefrontResult: mockstore.DeleteFriendResult(storefront.ErrFriendNotFound),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := mockserver.New().WithStorefront(test.storefrontResult)
+
+			hub, err := ws.NewHub(ws.Config{}, srv)
+			if err != nil {
+				t.Fatalf("couldn't create test hub: %s", err.Error())
+			}
+
+			r := mux.NewRouter()
+			r.HandleFunc("/users/friends/{id}", Delete(srv, hub)).Methods(http.MethodDelete)
+
+			req, err := http.NewRequest(http.MethodDelete, "/users/friends/1c5e2f3a-7d4b-4e8a-9f60-3b2a1d0c9e87", nil)
+			if err != nil {
+				t.Fatalf("couldn't create test HTTP request: %s", err.Error())
+			}
+
+			req = req.WithContext(authentication.NewContext(req.Context(), mockauth.Principal))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedCode {
+				t.Fatalf("expected status code %03d but got %03d (body: %s)", test.expectedCode, rr.Code, rr.Body)
+			}
+		})
+	}
+}
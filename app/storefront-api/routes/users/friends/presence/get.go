@@ -0,0 +1,66 @@
+package presence
+
+import (
+	"net/http"
+
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/ws"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Get the presence of the user's friends
+func Get(srv webserver.Server, hub *ws.Hub) http.HandlerFunc {
+	if srv == nil || hub == nil {
+		log.Fatal().Msg("a nil dependency was passed to GET '/users/friends/presence'")
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Info().Msg("[GET /users/friends/presence] Received a request")
+
+		// Get the user authenticated by the middleware
+		principal, ok := authentication.FromContext(r.Context())
+		if !ok {
+			log.Error().Msg("[GET /users/friends/presence] Request was not authenticated")
+			response.Error(w, r, http.StatusUnauthorized, "Invalid authorization token")
+			return
+		}
+
+		sublogger := log.With().Any("user", principal.UserID).Logger()
+
+		friendsList, err := srv.GetAllFriends(r.Context(), principal.UserID)
+		if err != nil {
+			sublogger.Info().Msgf("[GET /users/friends/presence] Error getting friends from the database, %s", err.Error())
+
+			response.Error(w, r, http.StatusInternalServerError, "Error retrieving user friends list")
+			return
+		}
+
+		// Friendships are two-way, so every friend shares their presence with the
+		// user and only friends do
+		presenceList := make([]dtos.Presence, 0, len(friendsList))
+		for _, friend := range friendsList {
+			// Connected friends are known to the hub, the others were stored when they disconnected
+			if p, ok := hub.Presence(friend.Id); ok {
+				presenceList = append(presenceList, p)
+				continue
+			}
+
+			p, err := srv.GetPresence(r.Context(), friend.Id)
+			if err != nil {
+				sublogger.Info().Msgf("[GET /users/friends/presence] Error getting presence of %s from the database, %s", friend.Id, err.Error())
+
+				response.Error(w, r, http.StatusInternalServerError, "Error retrieving friends presence")
+				return
+			}
+			presenceList = append(presenceList, p)
+		}
+
+		sublogger.Info().Msg("[GET /users/friends/presence] Successfully retrieved friends presence")
+
+		response.Write(w, http.StatusOK, "Friends presence retrieved", response.Data{"presence": presenceList})
+	}
+}
@@ -0,0 +1,128 @@
+package presence
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mockserver "github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver/mock"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/ws"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
+	mockauth "github.com/anthonydip/flutter-messenger-go/pkg/authentication/mock"
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+)
+
+func TestGet(t *testing.T) {
+	t.Parallel()
+
+	// The mock user is the only friend of every user of the storefront mock
+	friendID := "8ae84a23-fa49-45eb-8000-bdc9b9fe074a"
+
+	tests := map[string]struct {
+		connected        []string
+		expectedStatus   string
+		expectedLastSeen int64
+	}{
+		"offline": {
+			expectedStatus:   dtos.PresenceOffline,
+			expectedLastSeen: 1700000000000,
+		},
+		"online": {
+			connected:      []string{friendID},
+			expectedStatus: dtos.PresenceOnline,
+		},
+		"non-friend online": {
+			connected:        []string{"carol"},
+			expectedStatus:   dtos.PresenceOffline,
+			expectedLastSeen: 1700000000000,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := mockserver.New()
+
+			hub, err := ws.NewHub(ws.Config{}, srv)
+			if err != nil {
+				t.Fatalf("couldn't create test hub: %s", err.Error())
+			}
+			go hub.Run()
+
+			for _, userID := range test.connected {
+				connect(t, hub, userID)
+			}
+
+			r := mux.NewRouter()
+			r.HandleFunc("/users/friends/presence", Get(srv, hub)).Methods(http.MethodGet)
+
+			req, err := http.NewRequest(http.MethodGet, "/users/friends/presence", nil)
+			if err != nil {
+				t.Fatalf("couldn't create test HTTP request: %s", err.Error())
+			}
+
+			principal := mockauth.Principal
+			principal.UserID = "alice"
+			req = req.WithContext(authentication.NewContext(req.Context(), principal))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected status code 200 but got %03d (body: %s)", rr.Code, rr.Body)
+			}
+
+			res := struct {
+				Presence []dtos.Presence `json:"presence"`
+			}{}
+			if err := json.NewDecoder(rr.Body).Decode(&res); err != nil {
+				t.Fatalf("couldn't decode response: %s", err.Error())
+			}
+
+			// Only friends are listed
+			if len(res.Presence) != 1 || res.Presence[0].Id != friendID {
+				t.Fatalf("expected the presence of %s only but got %+v", friendID, res.Presence)
+			}
+
+			p := res.Presence[0]
+			if p.Status != test.expectedStatus || (test.expectedLastSeen != 0 && p.LastSeen != test.expectedLastSeen) {
+				t.Fatalf("expected status %s last seen at %d but got %+v", test.expectedStatus, test.expectedLastSeen, p)
+			}
+		})
+	}
+}
+
+// Connect a user to the hub over a websocket, waiting until the hub knows them
+func connect(t *testing.T, hub *ws.Hub, userID string) {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws.ServeWs(hub, w, r, userID, "token-id")
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("couldn't connect %s: %s", userID, err.Error())
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, ok := hub.Presence(userID); ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s to connect", userID)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,78 @@
+package requests
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/utils"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/ws"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// Cancel a friend request sent by the user
+func Delete(srv webserver.Server, hub *ws.Hub) http.HandlerFunc {
+	if srv == nil || hub == nil {
+		log.Fatal().Msg("a nil dependency was passed to DELETE '/users/friends/requests/{id}'")
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get the requestID
+		params := mux.Vars(r)
+		requestID := strings.TrimSpace(params["id"])
+
+		sublogger := log.With().Any("requestID", requestID).Logger()
+		sublogger.Info().Msg("[DELETE /users/friends/requests/{id}] Received a request")
+
+		// Get the user authenticated by the middleware
+		principal, ok := authentication.FromContext(r.Context())
+		if !ok {
+			sublogger.Error().Msg("[DELETE /users/friends/requests/{id}] Request was not authenticated")
+			response.Error(w, r, http.StatusUnauthorized, "Invalid authorization token")
+			return
+		}
+
+		request, err := srv.GetFriendRequest(r.Context(), requestID)
+		if err != nil && !errors.Is(err, storefront.ErrFriendRequestNotFound) {
+			sublogger.Error().Msgf("[DELETE /users/friends/requests/{id}] Error getting friend request from the database, %s", err.Error())
+			response.Error(w, r, http.StatusInternalServerError, "Error getting friend request")
+			return
+		}
+
+		// Only the sender can cancel a request, others are treated as not existing
+		if request.From != principal.UserID {
+			sublogger.Info().Msg("[DELETE /users/friends/requests/{id}] Friend request does not exist")
+			response.Error(w, r, http.StatusNotFound, "Friend request does not exist")
+			return
+		}
+
+		err = srv.DeleteFriendRequest(r.Context(), request.Id)
+		if err != nil {
+			code := utils.ErrorStatus(err)
+			msg := "Error cancelling friend request"
+
+			if errors.Is(err, storefront.ErrFriendRequestNotFound) {
+				sublogger.Info().Msg("[DELETE /users/friends/requests/{id}] Friend request no longer exists")
+				msg = "Friend request does not exist"
+			} else {
+				sublogger.Error().Msgf("[DELETE /users/friends/requests/{id}] Error cancelling friend request, %s", err.Error())
+			}
+
+			response.Error(w, r, code, msg)
+			return
+		}
+
+		// Remove the request from the recipient's pending requests in real time
+		hub.NotifyFriendRequest(request.To, request, ws.StateCancelled)
+
+		sublogger.Info().Msg("[DELETE /users/friends/requests/{id}] Successfully cancelled friend request")
+
+		response.Write(w, http.StatusOK, "Friend request successfully cancelled", nil)
+	}
+}
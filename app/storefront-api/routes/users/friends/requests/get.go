@@ -0,0 +1,55 @@
+package requests
+
+import (
+	"net/http"
+
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Get the user's pending incoming or outgoing friend requests
+func Get(srv webserver.Server) http.HandlerFunc {
+	if srv == nil {
+		log.Fatal().Msg("a nil dependency was passed to GET '/users/friends/requests'")
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Requests received by the user are listed unless asked otherwise
+		direction := r.URL.Query().Get("direction")
+		if direction == "" {
+			direction = dtos.FriendRequestIncoming
+		}
+
+		sublogger := log.With().Any("direction", direction).Logger()
+		sublogger.Info().Msg("[GET /users/friends/requests] Received a request")
+
+		if direction != dtos.FriendRequestIncoming && direction != dtos.FriendRequestOutgoing {
+			sublogger.Info().Msg("[GET /users/friends/requests] Invalid direction provided")
+			response.Error(w, r, http.StatusBadRequest, "Invalid direction")
+			return
+		}
+
+		// Get the user authenticated by the middleware
+		principal, ok := authentication.FromContext(r.Context())
+		if !ok {
+			sublogger.Error().Msg("[GET /users/friends/requests] Request was not authenticated")
+			response.Error(w, r, http.StatusUnauthorized, "Invalid authorization token")
+			return
+		}
+
+		requests, err := srv.GetFriendRequests(r.Context(), principal.UserID, direction)
+		if err != nil {
+			sublogger.Error().Msgf("[GET /users/friends/requests] Error getting friend requests from the database, %s", err.Error())
+			response.Error(w, r, http.StatusInternalServerError, "Error getting friend requests")
+			return
+		}
+
+		sublogger.Info().Msgf("[GET /users/friends/requests] Successfully retrieved %d friend requests", len(requests))
+
+		response.Write(w, http.StatusOK, "Friend requests retrieved", response.Data{"requests": requests})
+	}
+}
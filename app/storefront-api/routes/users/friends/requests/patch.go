@@ -0,0 +1,117 @@
+package requests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/utils"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/ws"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// Answers to a friend request
+const (
+	ActionAccept  = "accept"
+	ActionDecline = "decline"
+)
+
+type AnswerRequest struct {
+	Action string `json:"action,omitempty"`
+}
+
+// Accept or decline a friend request received by the user
+func Patch(srv webserver.Server, hub *ws.Hub) http.HandlerFunc {
+	if srv == nil || hub == nil {
+		log.Fatal().Msg("a nil dependency was passed to PATCH '/users/friends/requests/{id}'")
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get the requestID
+		params := mux.Vars(r)
+		requestID := strings.TrimSpace(params["id"])
+
+		answer := AnswerRequest{}
+
+		r.Body = http.MaxBytesReader(w, r.Body, 1048576)
+
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+
+		err := dec.Decode(&answer)
+		if err != nil {
+			log.Error().Msg("[PATCH /users/friends/requests/{id}] Unable to decode answer")
+
+			response.Error(w, r, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		sublogger := log.With().Any("requestID", requestID).Any("request", answer).Logger()
+		sublogger.Info().Msg("[PATCH /users/friends/requests/{id}] Received a request")
+
+		if answer.Action != ActionAccept && answer.Action != ActionDecline {
+			sublogger.Info().Msg("[PATCH /users/friends/requests/{id}] Invalid action provided")
+			response.Error(w, r, http.StatusBadRequest, "Invalid action")
+			return
+		}
+
+		// Get the user authenticated by the middleware
+		principal, ok := authentication.FromContext(r.Context())
+		if !ok {
+			sublogger.Error().Msg("[PATCH /users/friends/requests/{id}] Request was not authenticated")
+			response.Error(w, r, http.StatusUnauthorized, "Invalid authorization token")
+			return
+		}
+
+		request, err := srv.GetFriendRequest(r.Context(), requestID)
+		if err != nil && !errors.Is(err, storefront.ErrFriendRequestNotFound) {
+			sublogger.Error().Msgf("[PATCH /users/friends/requests/{id}] Error getting friend request from the database, %s", err.Error())
+			response.Error(w, r, http.StatusInternalServerError, "Error getting friend request")
+			return
+		}
+
+		// Only the recipient can answer a request, others are treated as not existing
+		if request.To != principal.UserID {
+			sublogger.Info().Msg("[PATCH /users/friends/requests/{id}] Friend request does not exist")
+			response.Error(w, r, http.StatusNotFound, "Friend request does not exist")
+			return
+		}
+
+		if answer.Action == ActionAccept {
+			err = srv.AcceptFriendRequest(r.Context(), request.Id)
+		} else {
+			err = srv.DeleteFriendRequest(r.Context(), request.Id)
+		}
+		if err != nil {
+			code := utils.ErrorStatus(err)
+			msg := "Error answering friend request"
+
+			if errors.Is(err, storefront.ErrFriendRequestNotFound) {
+				sublogger.Info().Msg("[PATCH /users/friends/requests/{id}] Friend request no longer exists")
+				msg = "Friend request does not exist"
+			} else {
+				sublogger.Error().Msgf("[PATCH /users/friends/requests/{id}] Error answering friend request, %s", err.Error())
+			}
+
+			response.Error(w, r, code, msg)
+			return
+		}
+
+		// The sender is only told about accepted requests
+		if answer.Action == ActionAccept {
+			hub.InvalidateFriends(request.From, request.To)
+			hub.NotifyFriendRequest(request.From, request, ws.StateAccepted)
+		}
+
+		sublogger.Info().Msgf("[PATCH /users/friends/requests/{id}] Successfully answered friend request with %s", answer.Action)
+
+		response.Write(w, http.StatusOK, "Friend request successfully answered", nil)
+	}
+}
@@ -0,0 +1,77 @@
+package requests
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockserver "github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver/mock"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/ws"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
+	mockauth "github.com/anthonydip/flutter-messenger-go/pkg/authentication/mock"
+
+	"github.com/gorilla/mux"
+)
+
+func TestPatch(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		requestID    string
+		requestBody  string
+		expectedCode int
+	}{
+		// The storefront mock's request is from the first user of its id to the second
+		"accepted": {
+			requestID:    "1c5e2f3a-7d4b-4e8a-9f60-3b2a1d0c9e87_8ae84a23-fa49-45eb-8000-bdc9b9fe074a",
+			requestBody:  `{"action": "accept"}`,
+			expectedCode: 200,
+		},
+		"declined": {
+			requestID:    "1c5e2f3a-7d4b-4e8a-9f60-3b2a1d0c9e87_8ae84a23-fa49-45eb-8000-bdc9b9fe074a",
+			requestBody:  `{"action": "decline"}`,
+			expectedCode: 200,
+		},
+		"invalid action": {
+			requestID:    "1c5e2f3a-7d4b-4e8a-9f60-3b2a1d0c9e87_8ae84a23-fa49-45eb-8000-bdc9b9fe074a",
+			requestBody:  `{"action": "ignore"}`,
+			expectedCode: 400,
+		},
+		"sent by the user": {
+			requestID:    "8ae84a23-fa49-45eb-8000-bdc9b9fe074a_1c5e2f3a-7d4b-4e8a-9f60-3b2a1d0c9e87",
+			requestBody:  `{"action": "accept"}`,
+			expectedCode: 404,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := mockserver.New()
+
+			hub, err := ws.NewHub(ws.Config{}, srv)
+			if err != nil {
+				t.Fatalf("couldn't create test hub: %s", err.Error())
+			}
+			go hub.Run()
+
+			r := mux.NewRouter()
+			r.HandleFunc("/users/friends/requests/{id}", Patch(srv, hub)).Methods(http.MethodPatch)
+
+			req, err := http.NewRequest(http.MethodPatch, "/users/friends/requests/"+test.requestID, bytes.NewBuffer([]byte(test.requestBody)))
+			if err != nil {
+				t.Fatalf("couldn't create test HTTP request: %s", err.Error())
+			}
+
+			req.Header.Add("Content-Type", "application/json")
+			req = req.WithContext(authentication.NewContext(req.Context(), mockauth.Principal))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedCode {
+				t.Fatalf("expected status code %03d but got %03d (body: %s)", test.expectedCode, rr.Code, rr.Body)
+			}
+		})
+	}
+}
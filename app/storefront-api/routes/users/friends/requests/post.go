@@ -0,0 +1,153 @@
+package requests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/utils"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/ws"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+
+	"github.com/rs/zerolog/log"
+)
+
+type FriendRequest struct {
+	Email string `json:"email,omitempty"`
+}
+
+// Send a friend request
+func Post(srv webserver.Server, hub *ws.Hub) http.HandlerFunc {
+	if srv == nil || hub == nil {
+		log.Fatal().Msg("a nil dependency was passed to POST '/users/friends/requests'")
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		friend := FriendRequest{}
+
+		r.Body = http.MaxBytesReader(w, r.Body, 1048576)
+
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+
+		err := dec.Decode(&friend)
+		if err != nil {
+			log.Error().Msg("[POST /users/friends/requests] Unable to decode friend request")
+
+			response.Error(w, r, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		log.Info().Msgf("[POST /users/friends/requests] Received a request: %+v", friend)
+
+		sublogger := log.With().Any("request", friend).Logger()
+
+		// Validate the email
+		err = utils.ValidateEmail(friend.Email)
+		if err != nil {
+			sublogger.Error().Msgf("[POST /users/friends/requests] Invalid email provided")
+			response.Error(w, r, http.StatusBadRequest, "Invalid email")
+			return
+		}
+
+		// Get the user authenticated by the middleware
+		principal, ok := authentication.FromContext(r.Context())
+		if !ok {
+			sublogger.Error().Msg("[POST /users/friends/requests] Request was not authenticated")
+			response.Error(w, r, http.StatusUnauthorized, "Invalid authorization token")
+			return
+		}
+
+		// Retrieve the friend from the email
+		friendUser, err := srv.GetUserByEmail(r.Context(), friend.Email)
+		if err != nil {
+			code := utils.ErrorStatus(err)
+			msg := "Error retrieving friend"
+
+			if errors.Is(err, storefront.ErrUserNotFound) {
+				sublogger.Info().Msgf("[POST /users/friends/requests] Friend does not exist")
+				msg = "Friend does not exist"
+			} else {
+				sublogger.Error().Msgf("[POST /users/friends/requests] Error retrieving friend from the database")
+			}
+
+			response.Error(w, r, code, msg)
+			return
+		}
+
+		// Check if user is attempting to add themself
+		if principal.UserID == friendUser.Id {
+			sublogger.Error().Msgf("[POST /users/friends/requests] User attempted to add themself as a friend")
+			response.Error(w, r, http.StatusConflict, "Invalid friend")
+			return
+		}
+
+		// Users who blocked the user are treated as not existing
+		blocked, err := srv.IsBlocked(r.Context(), friendUser.Id, principal.UserID)
+		if err != nil {
+			sublogger.Error().Msgf("[POST /users/friends/requests] Error checking block list of friend, %s", err.Error())
+			response.Error(w, r, http.StatusInternalServerError, "Error sending friend request")
+			return
+		}
+
+		if blocked {
+			sublogger.Info().Msgf("[POST /users/friends/requests] Friend blocked the user")
+			response.Error(w, r, http.StatusNotFound, "Friend does not exist")
+			return
+		}
+
+		// Users must unblock a user before sending them a friend request
+		blocked, err = srv.IsBlocked(r.Context(), principal.UserID, friendUser.Id)
+		if err != nil {
+			sublogger.Error().Msgf("[POST /users/friends/requests] Error checking block list of user, %s", err.Error())
+			response.Error(w, r, http.StatusInternalServerError, "Error sending friend request")
+			return
+		}
+
+		if blocked {
+			sublogger.Info().Msgf("[POST /users/friends/requests] User blocked the friend")
+			response.Error(w, r, http.StatusConflict, "Friend is blocked")
+			return
+		}
+
+		// Attempt to send the friend request
+		request, err := srv.PostFriendRequest(r.Context(), dtos.FriendRequest{
+			From:      principal.UserID,
+			To:        friendUser.Id,
+			Timestamp: time.Now().UnixMilli(),
+		})
+		if err != nil {
+			code := utils.ErrorStatus(err)
+			msg := "Error sending friend request"
+
+			switch {
+			case errors.Is(err, storefront.ErrFriendAlreadyAdded):
+				sublogger.Info().Msgf("[POST /users/friends/requests] Friend already added")
+				msg = "Friend already added"
+			case errors.Is(err, storefront.ErrFriendRequestSent):
+				sublogger.Info().Msgf("[POST /users/friends/requests] Friend request already sent")
+				msg = "Friend request already sent"
+			case errors.Is(err, storefront.ErrFriendRequestReceived):
+				sublogger.Info().Msgf("[POST /users/friends/requests] Friend request already received from the friend")
+				msg = "Friend request already received"
+			default:
+				sublogger.Error().Msgf("[POST /users/friends/requests] Error sending friend request, %s", err.Error())
+			}
+
+			response.Error(w, r, code, msg)
+			return
+		}
+
+		// Let the friend know about the request in real time
+		hub.NotifyFriendRequest(request.To, request, ws.StateRequested)
+
+		sublogger.Info().Msgf("[POST /users/friends/requests] Successfully sent friend request: %+v", request)
+
+		response.Write(w, http.StatusCreated, "Friend request successfully sent", response.Data{"request": &request})
+	}
+}
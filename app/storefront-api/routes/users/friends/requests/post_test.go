@@ -0,0 +1,91 @@
+package requests
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockserver "github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver/mock"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/ws"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	mockstore "github.com/anthonydip/flutter-messenger-go/internal/storefront/mock"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
+	mockauth "github.com/anthonydip/flutter-messenger-go/pkg/authentication/mock"
+
+	"github.com/gorilla/mux"
+)
+
+func TestPost(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		requestBody       string
+		expectedCode      int
+		storefrontResults []mockstore.Result
+	}{
+		"sent": {
+			requestBody:  `{"email": "friend@storefront-mock.com"}`,
+			expectedCode: 201,
+		},
+		"yourself": {
+			requestBody:  `{"email": "mock@storefront-mock.com"}`,
+			expectedCode: 409,
+		},
+		"invalid email": {
+			requestBody:  `{"email": "friend"}`,
+			expectedCode: 400,
+		},
+		"friend not found": {
+			requestBody:  `{"email": "friend@storefront-mock.com"}`,
+			expectedCode: 404,
+			storefrontResults: []mockstore.Result{
+				mockstore.GetUserByEmailResult(storefront.ErrUserNotFound),
+			},
+		},
+		"blocked": {
+			requestBody:  `{"email": "friend@storefront-mock.com"}`,
+			expectedCode: 404,
+			storefrontResults: []mockstore.Result{
+				mockstore.IsBlockedSucceed(),
+			},
+		},
+		"already sent": {
+			requestBody:  `{"email": "friend@storefront-mock.com"}`,
+			expectedCode: 409,
+			storefrontResults: []mockstore.Result{
+				mockstore.PostFriendRequestResult(storefront.ErrFriendRequestSent),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := mockserver.New().WithStorefront(test.storefrontResults...)
+
+			hub, err := ws.NewHub(ws.Config{}, srv)
+			if err != nil {
+				t.Fatalf("couldn't create test hub: %s", err.Error())
+			}
+			go hub.Run()
+
+			r := mux.NewRouter()
+			r.HandleFunc("/users/friends/requests", Post(srv, hub)).Methods(http.MethodPost)
+
+			req, err := http.NewRequest(http.MethodPost, "/users/friends/requests", bytes.NewBuffer([]byte(test.requestBody)))
+			if err != nil {
+				t.Fatalf("couldn't create test HTTP request: %s", err.Error())
+			}
+
+			req.Header.Add("Content-Type", "application/json")
+			req = req.WithContext(authentication.NewContext(req.Context(), mockauth.Principal))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != test.expectedCode {
+				t.Fatalf("expected status code %03d but got %03d (body: %s)", test.expectedCode, rr.Code, rr.Body)
+			}
+		})
+	}
+}
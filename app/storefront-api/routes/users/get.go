@@ -1,11 +1,14 @@
 package users
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/utils"
 	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/zerolog/log"
@@ -18,8 +21,6 @@ func Get(srv webserver.Server) http.HandlerFunc {
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-
 		// Get the userID
 		params := mux.Vars(r)
 		userID := strings.TrimSpace(params["id"])
@@ -37,81 +38,52 @@ func Get(srv webserver.Server) http.HandlerFunc {
 
 		if idType != "email" && idType != "id" {
 			sublogger.Info().Msgf("[GET /users/{userID}] Invalid idType %s", idType)
-			res := Response{
-				Status:        "BAD REQUEST",
-				StatusCode:    400,
-				StatusMessage: "Invalid ID type in query parameter",
-			}
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(&res)
+			response.Error(w, r, http.StatusBadRequest, "Invalid ID type in query parameter")
 			return
 		}
 
 		if idType == "id" {
 			// Get the user from the userID
-			user, err := srv.GetUser(userID)
+			user, err := srv.GetUser(r.Context(), userID)
 			if err != nil {
-				if err.Error() == "user not found" {
-					sublogger.Info().Msg("[GET /users/{userID}] User does not exist")
+				code := utils.ErrorStatus(err)
+				msg := "Error retrieving user"
 
-					res := Response{
-						Status:        "NOT FOUND",
-						StatusCode:    404,
-						StatusMessage: "User does not exist",
-					}
-					w.WriteHeader(http.StatusNotFound)
-					json.NewEncoder(w).Encode(&res)
-					return
+				if errors.Is(err, storefront.ErrUserNotFound) {
+					sublogger.Info().Msg("[GET /users/{userID}] User does not exist")
+					msg = "User does not exist"
 				} else {
 					sublogger.Info().Msgf("[GET /users/{userID}] Error getting user from the database, %s", err.Error())
-
-					res := Response{
-						Status:        "INTERNAL SERVER ERROR",
-						StatusCode:    500,
-						StatusMessage: "Error retrieving user",
-					}
-					w.WriteHeader(http.StatusInternalServerError)
-					json.NewEncoder(w).Encode(&res)
-					return
 				}
+
+				response.Error(w, r, code, msg)
+				return
 			}
 
 			sublogger.Info().Msgf("[GET /users/{userID}] Successfully retrieved user: %+v", user)
 
-			res := Response{
-				Status:        "SUCCESS",
-				StatusCode:    200,
-				StatusMessage: "User exists",
-				User:          &user,
-			}
-			w.WriteHeader(http.StatusOK)
-			json.NewEncoder(w).Encode(&res)
+			response.Write(w, http.StatusOK, "User exists", response.Data{"user": &user})
 		} else {
 			// Get the user from email
-			user, err := srv.GetUserByEmail(userID)
+			user, err := srv.GetUserByEmail(r.Context(), userID)
 			if err != nil {
-				sublogger.Info().Msg("[GET /users/{userID}] User does not exist")
+				code := utils.ErrorStatus(err)
+				msg := "Error retrieving user"
 
-				res := Response{
-					Status:        "NOT FOUND",
-					StatusCode:    404,
-					StatusMessage: "User does not exist",
+				if errors.Is(err, storefront.ErrUserNotFound) {
+					sublogger.Info().Msg("[GET /users/{userID}] User does not exist")
+					msg = "User does not exist"
+				} else {
+					sublogger.Error().Msgf("[GET /users/{userID}] Error getting user from the database, %s", err.Error())
 				}
-				w.WriteHeader(http.StatusNotFound)
-				json.NewEncoder(w).Encode(&res)
+
+				response.Error(w, r, code, msg)
 				return
 			}
 
 			sublogger.Info().Msgf("[GET /users/{userID}] Successfully retrieved user: %+v", user)
 
-			res := Response{
-				Status:        "SUCCESS",
-				StatusCode:    200,
-				StatusMessage: "User found",
-				User:          &user,
-			}
-			w.WriteHeader(http.StatusOK)
-			json.NewEncoder(w).Encode(&res)
+			response.Write(w, http.StatusOK, "User found", response.Data{"user": &user})
 		}
 
 	}
@@ -1,12 +1,12 @@
 package users
 
 import (
-	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	mockserver "github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver/mock"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
 	mockstore "github.com/anthonydip/flutter-messenger-go/internal/storefront/mock"
 
 	"github.com/gorilla/mux"
@@ -24,7 +24,7 @@ func TestGet(t *testing.T) {
 		},
 		"not found": {
 			expectedCode:     404,
-			storefrontResult: mockstore.GetUserResult(errors.New("user not found")),
+			storefrontResult: mockstore.GetUserResult(storefront.ErrUserNotFound),
 		},
 	}
 
@@ -2,22 +2,18 @@ package users
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
 	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/utils"
 	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
 	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
 
 	"github.com/rs/zerolog/log"
 )
 
-type Response struct {
-	Status        string     `json:"status"`
-	StatusCode    int        `json:"statusCode"`
-	StatusMessage string     `json:"statusMessage,omitempty"`
-	User          *dtos.User `json:"user,omitempty"`
-}
-
 // Create a user
 func Post(srv webserver.Server) http.HandlerFunc {
 	if srv == nil {
@@ -25,8 +21,6 @@ func Post(srv webserver.Server) http.HandlerFunc {
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-
 		user := dtos.User{}
 
 		r.Body = http.MaxBytesReader(w, r.Body, 1048576)
@@ -38,15 +32,7 @@ func Post(srv webserver.Server) http.HandlerFunc {
 		if err != nil {
 			log.Error().Msg("[POST /users] Unable to decode user")
 
-			w.WriteHeader(http.StatusBadRequest)
-
-			res := Response{
-				Status:        "BAD REQUEST",
-				StatusCode:    400,
-				StatusMessage: "Invalid request body",
-			}
-
-			json.NewEncoder(w).Encode(&res)
+			response.Error(w, r, http.StatusBadRequest, "Invalid request body")
 			return
 		}
 
@@ -58,76 +44,46 @@ func Post(srv webserver.Server) http.HandlerFunc {
 		// Validate the user request
 		err = utils.ValidatePostUser(user)
 		if err != nil {
-			res := Response{
-				Status:     "BAD REQUEST",
-				StatusCode: 400,
-			}
-			w.WriteHeader(http.StatusBadRequest)
+			code := utils.ErrorStatus(err)
+			msg := "Error validating user request"
 
-			switch err.Error() {
-			case "invalid email":
+			switch {
+			case errors.Is(err, utils.ErrInvalidEmail):
 				sublogger.Error().Msgf("[POST /users] Invalid email, received %s", user.Email)
-				res.StatusMessage = "Invalid email"
-			case "invalid provider":
+				msg = "Invalid email"
+			case errors.Is(err, utils.ErrInvalidProvider):
 				sublogger.Error().Msgf("[POST /users] Invalid provider, received %s", user.Provider)
-				res.StatusMessage = "Invalid provider"
-			case "invalid password":
+				msg = "Invalid provider"
+			case errors.Is(err, utils.ErrInvalidPassword):
 				sublogger.Error().Msg("[POST /users] Invalid password")
-				res.StatusMessage = "Invalid password, criteria not met"
+				msg = "Invalid password, criteria not met"
 			default:
 				sublogger.Error().Msgf("[POST /users] Error occurred validating user request, %v", err)
-				res = Response{
-					Status:        "INTERNAL SERVER ERROR",
-					StatusCode:    500,
-					StatusMessage: "Error validating user request",
-				}
-				w.WriteHeader(http.StatusInternalServerError)
 			}
 
-			json.NewEncoder(w).Encode(&res)
+			response.Error(w, r, code, msg, utils.FieldErrors(err)...)
 			return
 		}
 
-		result, err := srv.PostUser(user)
+		result, err := srv.PostUser(r.Context(), user)
 
 		if err != nil {
-			res := Response{}
+			code := utils.ErrorStatus(err)
+			msg := "Error creating user"
 
-			if err.Error() == "409 Conflict" {
+			if errors.Is(err, storefront.ErrEmailTaken) {
 				sublogger.Error().Msgf("[POST /users] User already exists with email: %s", user.Email)
-
-				res = Response{
-					Status:        "CONFLICT",
-					StatusCode:    409,
-					StatusMessage: "User already exists",
-				}
-
-				w.WriteHeader(http.StatusConflict)
-				json.NewEncoder(w).Encode(&res)
+				msg = "User already exists"
 			} else {
 				sublogger.Error().Msgf("[POST /users] Posting new user failed: %v", err.Error())
-
-				res = Response{
-					Status:     "INTERNAL SERVER ERROR",
-					StatusCode: 500,
-				}
-
-				w.WriteHeader(http.StatusInternalServerError)
-				json.NewEncoder(w).Encode(&res)
 			}
+
+			response.Error(w, r, code, msg)
 			return
 		}
 
 		sublogger.Info().Msgf("[POST /users] Successfully created user: %+v", result)
 
-		res := Response{
-			Status:        "CREATED",
-			StatusCode:    201,
-			StatusMessage: "User successfully created",
-			User:          &result,
-		}
-
-		w.WriteHeader(http.StatusCreated)
-		json.NewEncoder(w).Encode(&res)
+		response.Write(w, http.StatusCreated, "User successfully created", response.Data{"user": &result})
 	}
 }
@@ -2,12 +2,14 @@ package users
 
 import (
 	"bytes"
-	"errors"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
 	mockserver "github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver/mock"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
 	mockstore "github.com/anthonydip/flutter-messenger-go/internal/storefront/mock"
 
 	"github.com/gorilla/mux"
@@ -17,7 +19,9 @@ func TestPost(t *testing.T) {
 	t.Parallel()
 
 	tests := map[string]struct {
+		requestBody      string
 		expectedCode     int
+		expectedFields   int
 		storefrontResult mockstore.Result
 	}{
 		"created": {
@@ -25,7 +29,12 @@ func TestPost(t *testing.T) {
 		},
 		"conflict": {
 			expectedCode:     409,
-			storefrontResult: mockstore.PostUserResult(errors.New("409 Conflict")),
+			storefrontResult: mockstore.PostUserResult(storefront.ErrEmailTaken),
+		},
+		"invalid": {
+			requestBody:    `{"email": "mock", "provider": "Apple", "password": "test123123"}`,
+			expectedCode:   400,
+			expectedFields: 2,
 		},
 	}
 
@@ -36,14 +45,18 @@ func TestPost(t *testing.T) {
 			r := mux.NewRouter()
 			r.HandleFunc("/users", Post(srv)).Methods(http.MethodPost)
 
-			requestBody := []byte(`{"email": "mock@storefront-mock.com", "provider": "Flutter", "password": "test123123"}`)
+			requestBody := test.requestBody
+			if requestBody == "" {
+				requestBody = `{"email": "mock@storefront-mock.com", "provider": "Flutter", "password": "test123123"}`
+			}
 
-			req, err := http.NewRequest(http.MethodPost, "/users", bytes.NewBuffer(requestBody))
+			req, err := http.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(requestBody))
 			if err != nil {
 				t.Fatalf("couldn't create test HTTP request: %s", err.Error())
 			}
 
 			req.Header.Add("Content-Type", "application/json")
+			req.Header.Add("Accept", response.MediaTypeProblem)
 
 			rr := httptest.NewRecorder()
 			r.ServeHTTP(rr, req)
@@ -51,6 +64,18 @@ func TestPost(t *testing.T) {
 			if rr.Code != test.expectedCode {
 				t.Fatalf("expected status code %03d but got %03d (body: %s)", test.expectedCode, rr.Code, rr.Body)
 			}
+
+			// Every failing field is reported in the problem details
+			if test.expectedFields > 0 {
+				var res response.Problem
+				if err := json.NewDecoder(rr.Body).Decode(&res); err != nil {
+					t.Fatalf("couldn't decode problem: %s", err.Error())
+				}
+
+				if len(res.Errors) != test.expectedFields {
+					t.Fatalf("expected %d failing fields but got %+v", test.expectedFields, res.Errors)
+				}
+			}
 		})
 	}
 }
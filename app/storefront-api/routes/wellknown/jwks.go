@@ -0,0 +1,31 @@
+package wellknown
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver"
+	"github.com/rs/zerolog/log"
+)
+
+// Publish the public keys verifying access tokens. The JWK set is written as
+// is rather than in the envelope, as JWT libraries expect.
+func JWKS(srv webserver.Server) http.HandlerFunc {
+	if srv == nil {
+		log.Fatal().Msg("a nil dependency was passed to GET '/.well-known/jwks.json'")
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		jwks := srv.PublicKeys(r.Context())
+
+		// Verifiers refetch the keys once cached ones no longer verify a token
+		w.Header().Set("Content-Type", response.MediaTypeJSON)
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		w.WriteHeader(http.StatusOK)
+
+		if err := json.NewEncoder(w).Encode(jwks); err != nil {
+			log.Error().Msgf("[GET /.well-known/jwks.json] Error writing keys, %s", err.Error())
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package wellknown
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockserver "github.com/anthonydip/flutter-messenger-go/app/storefront-api/webserver/mock"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
+
+	"github.com/gorilla/mux"
+)
+
+func TestJWKS(t *testing.T) {
+	t.Parallel()
+
+	srv := mockserver.New()
+
+	r := mux.NewRouter()
+	r.HandleFunc("/.well-known/jwks.json", JWKS(srv)).Methods(http.MethodGet)
+
+	req, err := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	if err != nil {
+		t.Fatalf("couldn't create test HTTP request: %s", err.Error())
+	}
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status code 200 but got %03d (body: %s)", rr.Code, rr.Body)
+	}
+
+	var jwks authentication.JWKS
+	if err := json.NewDecoder(rr.Body).Decode(&jwks); err != nil {
+		t.Fatalf("couldn't decode keys: %s", err.Error())
+	}
+
+	if len(jwks.Keys) != 1 || jwks.Keys[0].Kid == "" || jwks.Keys[0].N == "" {
+		t.Fatalf("unexpected keys %+v", jwks)
+	}
+}
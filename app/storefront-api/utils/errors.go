@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
+)
+
+// Errors of the authorization header and of request validation
+var (
+	ErrEmptyHeader     = errors.New("empty header")
+	ErrInvalidHeader   = errors.New("invalid header")
+	ErrInvalidEmail    = errors.New("invalid email")
+	ErrInvalidProvider = errors.New("invalid provider")
+	ErrInvalidPassword = errors.New("invalid password")
+	ErrInvalidName     = errors.New("invalid name")
+)
+
+// Map an error to the HTTP status of its response, this is the one place
+// deciding it for every route. Errors it doesn't know are internal errors.
+func ErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrEmptyHeader),
+		errors.Is(err, ErrInvalidHeader),
+		errors.Is(err, authentication.ErrInvalidToken),
+		errors.Is(err, storefront.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrInvalidEmail),
+		errors.Is(err, ErrInvalidProvider),
+		errors.Is(err, ErrInvalidPassword),
+		errors.Is(err, ErrInvalidName),
+		errors.Is(err, storefront.ErrInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, storefront.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, storefront.ErrConflict):
+		return http.StatusConflict
+	}
+
+	return http.StatusInternalServerError
+}
@@ -1,7 +1,6 @@
 package utils
 
 import (
-	"fmt"
 	"strings"
 )
 
@@ -9,12 +8,12 @@ import (
 func GetAuthorizationToken(authHeader string) (string, error) {
 	// Check for empty authorization header
 	if authHeader == "" {
-		return "", fmt.Errorf("empty header")
+		return "", ErrEmptyHeader
 	}
 
 	parts := strings.SplitN(authHeader, " ", 2)
 	if len(parts) != 2 || parts[0] != "Bearer" {
-		return "", fmt.Errorf("invalid header")
+		return "", ErrInvalidHeader
 	}
 
 	token := parts[1]
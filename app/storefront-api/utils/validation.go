@@ -1,9 +1,12 @@
 package utils
 
 import (
-	"fmt"
+	"errors"
 	"net/mail"
+	"strings"
+	"unicode/utf8"
 
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
 	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
 	"github.com/caitlin615/nist-password-validator/password"
 )
@@ -12,23 +15,63 @@ import (
 func ValidateEmail(email string) error {
 	_, err := mail.ParseAddress(email)
 	if err != nil {
-		return fmt.Errorf("invalid email")
+		return ErrInvalidEmail
 	}
 
 	return nil
 }
 
-// Function to validate the fields from a create user request
+// ValidationError lists every field of a request which failed validation
+type ValidationError struct {
+	Fields []response.FieldError
+	errs   []error
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Field + ": " + field.Message
+	}
+
+	return strings.Join(messages, ", ")
+}
+
+// Unwrap returns the error of each failing field, so that errors.Is matches
+// any of them
+func (e *ValidationError) Unwrap() []error {
+	return e.errs
+}
+
+func (e *ValidationError) add(field string, err error, message string) {
+	e.Fields = append(e.Fields, response.FieldError{Field: field, Message: message})
+	e.errs = append(e.errs, err)
+}
+
+// Fields of a request which failed validation, or nil if err is not a
+// ValidationError
+func FieldErrors(err error) []response.FieldError {
+	var invalid *ValidationError
+	if errors.As(err, &invalid) {
+		return invalid.Fields
+	}
+
+	return nil
+}
+
+// Function to validate the fields from a create user request, reporting
+// every failing field
 func ValidatePostUser(user dtos.User) error {
+	invalid := &ValidationError{}
+
 	// Validate the email address
 	_, err := mail.ParseAddress(user.Email)
 	if err != nil {
-		return fmt.Errorf("invalid email")
+		invalid.add("email", ErrInvalidEmail, "Invalid email")
 	}
 
 	// Validate the provider
 	if user.Provider != "Google" && user.Provider != "Flutter" {
-		return fmt.Errorf("invalid provider")
+		invalid.add("provider", ErrInvalidProvider, "Invalid provider")
 	}
 
 	// Validate the password if it is not through Google
@@ -36,9 +79,22 @@ func ValidatePostUser(user dtos.User) error {
 		validator := password.NewValidator(true, 8, 64)
 		err = validator.ValidatePassword(user.Password)
 		if err != nil {
-			return fmt.Errorf("invalid password")
+			invalid.add("password", ErrInvalidPassword, "Invalid password, criteria not met")
 		}
 	}
 
+	if len(invalid.errs) > 0 {
+		return invalid
+	}
+
+	return nil
+}
+
+// Function to validate the name of a group
+func ValidateGroupName(name string) error {
+	if name == "" || utf8.RuneCountInString(name) > 64 {
+		return ErrInvalidName
+	}
+
 	return nil
 }
@@ -1,6 +1,10 @@
 package webserver
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/ws"
 	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
 	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
 )
@@ -9,11 +13,21 @@ import (
 type Config struct {
 	Auth       authentication.Config
 	Storefront storefront.Config
+	Hub        ws.Config
 
 	Port int
+
+	// Deadline of each HTTP request, cancelling its storefront and
+	// authentication calls once it passes. Requests have no deadline when zero,
+	// websocket connections are never cut short by it.
+	RequestTimeout time.Duration
 }
 
 func validateConfig(cfg Config) error {
 	// This function would be used to validate a hydrated configuration; return an error if its invalid.
+	if cfg.RequestTimeout < 0 {
+		return fmt.Errorf("negative request timeout %s", cfg.RequestTimeout)
+	}
+
 	return nil
 }
@@ -0,0 +1,22 @@
+package webserver
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Give every request a deadline, so that the storefront and authentication
+// calls made with its context are cancelled once it passes
+func withDeadline(next http.Handler, timeout time.Duration) http.Handler {
+	if timeout <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
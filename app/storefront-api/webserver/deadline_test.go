@@ -0,0 +1,50 @@
+package webserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWithDeadline(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		timeout          time.Duration
+		expectedDeadline bool
+	}{
+		"Deadline": {
+			timeout:          time.Minute,
+			expectedDeadline: true,
+		},
+		"No Deadline": {
+			timeout:          0,
+			expectedDeadline: false,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var deadline time.Time
+			var ok bool
+			h := withDeadline(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				deadline, ok = r.Context().Deadline()
+			}), test.timeout)
+
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			if ok != test.expectedDeadline {
+				t.Fatalf("expected a deadline to be %t but got %t", test.expectedDeadline, ok)
+			}
+
+			if ok && time.Until(deadline) > test.timeout {
+				t.Fatalf("expected the deadline to be within %s but got %s", test.timeout, time.Until(deadline))
+			}
+		})
+	}
+}
@@ -1,12 +1,16 @@
 package webserver
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/ws"
 	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
@@ -16,6 +20,9 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// How long the requests in flight are given to finish when shutting down
+const shutdownTimeout = 10 * time.Second
+
 // Server exposes all functionalities of the Storefront API
 type Server interface {
 	authentication.Authentication
@@ -29,6 +36,7 @@ type Broker struct {
 
 	cfg    Config      // the api service's configuration
 	router *mux.Router // the api service's route collection
+	hub    *ws.Hub     // the api service's websocket hub
 }
 
 // Initialize a new Storefront API
@@ -51,15 +59,19 @@ func New(cfg Config) (*Broker, error) {
 		return nil, fmt.Errorf("invalid storefront configuration: %w", err)
 	}
 
+	r.hub, err = ws.NewHub(cfg.Hub, r.Storefront)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hub configuration: %w", err)
+	}
+
 	return r, nil
 }
 
 // Start the Storefront service
 func (bkr *Broker) Start(binder func(s Server, h *ws.Hub, r *mux.Router)) {
-	hub := ws.NewHub()
-	go hub.Run()
+	go bkr.hub.Run()
 	bkr.router = mux.NewRouter().StrictSlash(true)
-	binder(bkr, hub, bkr.router)
+	binder(bkr, bkr.hub, bkr.router)
 
 	l, err := net.Listen("tcp", ":"+strconv.Itoa(bkr.cfg.Port))
 	if err != nil {
@@ -69,9 +81,34 @@ func (bkr *Broker) Start(binder func(s Server, h *ws.Hub, r *mux.Router)) {
 		log.Info().Msgf("Starting webserver on TCP port %04d", bkr.cfg.Port)
 	}
 
-	if err := http.Serve(l, bkr.router); errors.Is(err, http.ErrServerClosed) {
-		log.Warn().Err(err).Msg("Web server has shut down")
-	} else {
+	server := &http.Server{Handler: withDeadline(bkr.router, bkr.cfg.RequestTimeout)}
+
+	// Shut down on SIGINT or SIGTERM, letting the requests in flight finish
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdown := make(chan struct{})
+	go func() {
+		defer close(shutdown)
+		<-ctx.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := server.Shutdown(ctx); err != nil {
+			log.Error().Err(err).Msg("Error shutting down web server")
+		}
+	}()
+
+	if err := server.Serve(l); !errors.Is(err, http.ErrServerClosed) {
 		log.Fatal().Err(err).Msg("Web server has shut down unexpectedly")
 	}
+	<-shutdown
+
+	// Stop reloading the keys
+	if err := bkr.Authentication.Close(); err != nil {
+		log.Error().Err(err).Msg("Error closing the auth agent")
+	}
+
+	log.Warn().Msg("Web server has shut down")
 }
@@ -0,0 +1,102 @@
+package ws
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Time after which an activity that was not started again is stopped by the
+// hub. Clients should resend a started frame more often than this.
+const defaultActivityTimeout = 10 * time.Second
+
+// activityKey identifies an activity of one user towards another
+type activityKey struct {
+	from     string
+	to       string
+	activity string
+}
+
+// activityTimer expires an activity unless it is replaced first
+type activityTimer struct {
+	key   activityKey
+	timer *time.Timer
+}
+
+// Handle an ephemeral activity, which is routed to connected clients only and
+// never stored or queued
+func (c *Client) handleActivity(frame Frame) {
+	if frame.To == "" || (frame.Activity != ActivityTyping && frame.Activity != ActivityRecording) {
+		c.reply(errorFrame(frame.Id, ErrInvalidFrame, "Activity requires a recipient and an activity of typing or recording"))
+		return
+	}
+
+	if frame.State != StateStarted && frame.State != StateStopped {
+		c.reply(errorFrame(frame.Id, ErrInvalidFrame, "Activity state must be started or stopped"))
+		return
+	}
+
+	// Only users who added the sender as a friend see their activity, checked
+	// against the cached friends as activities are sent on every keystroke
+	friends, err := c.hub.friends.get(c.ctx, frame.To)
+	if err != nil {
+		log.Error().Msgf("[/ws] Error checking friendship of %s and %s, %s", frame.To, c.userId, err.Error())
+		c.reply(errorFrame(frame.Id, ErrInternal, "Unable to send activity"))
+		return
+	}
+
+	if !friends[c.userId] {
+		c.reply(errorFrame(frame.Id, ErrForbidden, "Activity can only be sent to friends"))
+		return
+	}
+
+	c.hub.activity <- Frame{
+		Version:  ProtocolVersion,
+		Type:     TypeActivity,
+		From:     c.userId,
+		To:       frame.To,
+		Activity: frame.Activity,
+		State:    frame.State,
+	}
+}
+
+// Start or stop the expiry timer of an activity
+func (h *Hub) trackActivity(frame Frame) {
+	key := activityKey{from: frame.From, to: frame.To, activity: frame.Activity}
+
+	if a, ok := h.activities[key]; ok {
+		a.timer.Stop()
+		delete(h.activities, key)
+	}
+
+	if frame.State == StateStarted {
+		a := &activityTimer{key: key}
+		a.timer = time.AfterFunc(h.activityTimeout, func() {
+			h.expire <- a
+		})
+		h.activities[key] = a
+	}
+}
+
+// Stop an activity whose client never stopped it
+func (h *Hub) expireActivity(a *activityTimer) {
+	// The activity was stopped or started again since the timer fired
+	if h.activities[a.key] != a {
+		return
+	}
+	delete(h.activities, a.key)
+
+	key := a.key
+
+	h.route(&delivery{
+		userId: key.to,
+		frame: Frame{
+			Version:  ProtocolVersion,
+			Type:     TypeActivity,
+			From:     key.from,
+			To:       key.to,
+			Activity: key.activity,
+			State:    StateStopped,
+		},
+	})
+}
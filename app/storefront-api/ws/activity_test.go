@@ -0,0 +1,152 @@
+package ws
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+
+	mockstore "github.com/anthonydip/flutter-messenger-go/internal/storefront/mock"
+)
+
+func TestHandleActivity(t *testing.T) {
+	t.Parallel()
+
+	// Every user of the storefront mock has the mock user as their only friend
+	tests := map[string]struct {
+		from         string
+		frame        string
+		expectedType string
+		expectedCode string
+	}{
+		"typing": {
+			from:         "8ae84a23-fa49-45eb-8000-bdc9b9fe074a",
+			frame:        `{"v": 1, "type": "activity", "to": "bob", "activity": "typing", "state": "started"}`,
+			expectedType: TypeActivity,
+		},
+		"stranger": {
+			from:         "alice",
+			frame:        `{"v": 1, "type": "activity", "to": "bob", "activity": "typing", "state": "started"}`,
+			expectedType: TypeError,
+			expectedCode: ErrForbidden,
+		},
+		"unknown activity": {
+			from:         "8ae84a23-fa49-45eb-8000-bdc9b9fe074a",
+			frame:        `{"v": 1, "type": "activity", "to": "bob", "activity": "dancing", "state": "started"}`,
+			expectedType: TypeError,
+			expectedCode: ErrInvalidFrame,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			hub := newTestHub(t, mockstore.New())
+			go hub.Run()
+
+			alice := newTestClient(t, hub, test.from)
+			bob := newTestClient(t, hub, "bob")
+
+			alice.handle([]byte(test.frame))
+
+			if test.expectedType == TypeError {
+				reply := nextFrame(t, alice)
+				if reply.Type != TypeError || reply.Error.Code != test.expectedCode {
+					t.Fatalf("expected an %s error frame but got %+v", test.expectedCode, reply)
+				}
+				return
+			}
+
+			frame := nextFrame(t, bob)
+			if frame.Type != test.expectedType || frame.From != test.from || frame.State != StateStarted {
+				t.Fatalf("expected bob to receive alice's activity but got %+v", frame)
+			}
+		})
+	}
+}
+
+func TestActivityExpires(t *testing.T) {
+	t.Parallel()
+
+	hub := newTestHub(t, mockstore.New())
+	hub.activityTimeout = 20 * time.Millisecond
+	go hub.Run()
+
+	alice := newTestClient(t, hub, "8ae84a23-fa49-45eb-8000-bdc9b9fe074a")
+	bob := newTestClient(t, hub, "bob")
+
+	alice.handle([]byte(`{"v": 1, "type": "activity", "to": "bob", "activity": "recording", "state": "started"}`))
+
+	if frame := nextFrame(t, bob); frame.State != StateStarted {
+		t.Fatalf("expected a started activity but got %+v", frame)
+	}
+
+	frame := nextFrame(t, bob)
+	if frame.Type != TypeActivity || frame.Activity != ActivityRecording || frame.State != StateStopped {
+		t.Fatalf("expected the hub to stop the activity but got %+v", frame)
+	}
+}
+
+func TestActivityFriendsCached(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := storefront.NewMemory()
+
+	hub := newTestHub(t, store)
+	go hub.Run()
+
+	var users []dtos.User
+	for _, email := range []string{"alice@example.com", "bob@example.com"} {
+		user, err := store.PostUser(ctx, dtos.User{Email: email, Provider: "Google"})
+		if err != nil {
+			t.Fatalf("couldn't create user: %s", err.Error())
+		}
+		users = append(users, user)
+	}
+
+	request, err := store.PostFriendRequest(ctx, dtos.FriendRequest{From: users[0].Id, To: users[1].Id})
+	if err != nil {
+		t.Fatalf("couldn't send friend request: %s", err.Error())
+	}
+	if err := store.AcceptFriendRequest(ctx, request.Id); err != nil {
+		t.Fatalf("couldn't accept friend request: %s", err.Error())
+	}
+
+	alice := newTestClient(t, hub, users[0].Id)
+	bob := newTestClient(t, hub, users[1].Id)
+	typing := []byte(`{"v": 1, "type": "activity", "to": "` + bob.userId + `", "activity": "typing", "state": "started"}`)
+
+	// Friends are told of each other's presence, which is not what is tested
+	next := func(client *Client) Frame {
+		for {
+			if frame := nextFrame(t, client); frame.Type != TypePresence {
+				return frame
+			}
+		}
+	}
+
+	alice.handle(typing)
+	if frame := next(bob); frame.Type != TypeActivity {
+		t.Fatalf("expected bob to receive alice's activity but got %+v", frame)
+	}
+
+	// The friends are cached, so removing the friend goes unnoticed until the
+	// hub is told
+	if err := store.DeleteFriend(ctx, users[1].Id, users[0].Id); err != nil {
+		t.Fatalf("couldn't remove friend: %s", err.Error())
+	}
+
+	alice.handle(typing)
+	if frame := next(bob); frame.Type != TypeActivity {
+		t.Fatalf("expected bob to receive alice's activity but got %+v", frame)
+	}
+
+	hub.InvalidateFriends(users[0].Id, users[1].Id)
+
+	alice.handle(typing)
+	if reply := next(alice); reply.Type != TypeError || reply.Error.Code != ErrForbidden {
+		t.Fatalf("expected an %s error frame but got %+v", ErrForbidden, reply)
+	}
+}
@@ -0,0 +1,51 @@
+package ws
+
+// blockUpdate reports a user blocking or unblocking another user
+type blockUpdate struct {
+	userId    string
+	blockedId string
+	blocked   bool
+}
+
+// Tell the hub that a user blocked another user, so that no frame from either
+// of them reaches the other
+func (h *Hub) Block(userID string, blockedID string) {
+	h.block <- blockUpdate{userId: userID, blockedId: blockedID, blocked: true}
+}
+
+// Tell the hub that a user unblocked another user
+func (h *Hub) Unblock(userID string, blockedID string) {
+	h.block <- blockUpdate{userId: userID, blockedId: blockedID}
+}
+
+// Apply a block list change of a connected user
+func (h *Hub) updateBlock(b blockUpdate) {
+	blocked, ok := h.blocked[b.userId]
+	if !ok {
+		// The list is loaded when the user next connects
+		return
+	}
+
+	if b.blocked {
+		blocked[b.blockedId] = true
+	} else {
+		delete(blocked, b.blockedId)
+	}
+}
+
+// Check if either of two users blocked the other, as far as the block lists
+// of the connected users tell
+func (h *Hub) isBlocked(userID string, otherID string) bool {
+	return h.blocked[userID][otherID] || h.blocked[otherID][userID]
+}
+
+// Check if the client's user and another user blocked each other, in either
+// direction
+func (c *Client) blockedWith(userID string) (bool, error) {
+	blocked, err := c.hub.store.IsBlocked(c.ctx, userID, c.userId)
+	if err != nil || blocked {
+		return blocked, err
+	}
+
+	return c.hub.store.IsBlocked(c.ctx, c.userId, userID)
+}
@@ -0,0 +1,59 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	mockstore "github.com/anthonydip/flutter-messenger-go/internal/storefront/mock"
+)
+
+func TestHandleMessageBlocked(t *testing.T) {
+	t.Parallel()
+
+	hub := newTestHub(t, mockstore.New(mockstore.IsBlockedSucceed()))
+	go hub.Run()
+
+	alice := newTestClient(t, hub, "alice")
+	bob := newTestClient(t, hub, "bob")
+
+	alice.handle([]byte(`{"v": 1, "type": "message", "id": "c-1", "to": "bob", "body": "hello"}`))
+
+	reply := nextFrame(t, alice)
+	if reply.Type != TypeError || reply.Error.Code != ErrForbidden {
+		t.Fatalf("expected an %s error frame but got %+v", ErrForbidden, reply)
+	}
+
+	select {
+	case o := <-bob.send:
+		t.Fatalf("expected no message to be delivered but got %s", o.data)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRouteBlocked(t *testing.T) {
+	t.Parallel()
+
+	hub := newTestHub(t, mockstore.New())
+	go hub.Run()
+
+	bob := newTestClient(t, hub, "bob")
+	typing := Frame{Version: ProtocolVersion, Type: TypeActivity, From: "alice", To: "bob", Activity: ActivityTyping, State: StateStarted}
+
+	// Frames from a user blocked by the recipient are dropped by the hub
+	hub.Block("bob", "alice")
+	hub.deliver <- &delivery{userId: "bob", frame: typing}
+
+	select {
+	case o := <-bob.send:
+		t.Fatalf("expected no frame to be delivered but got %s", o.data)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	hub.Unblock("bob", "alice")
+	hub.deliver <- &delivery{userId: "bob", frame: typing}
+
+	frame := nextFrame(t, bob)
+	if frame.Type != TypeActivity || frame.From != "alice" {
+		t.Fatalf("expected bob to receive the activity once alice is unblocked but got %+v", frame)
+	}
+}
@@ -2,10 +2,14 @@ package ws
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"net/http"
 	"time"
 
+	"github.com/anthonydip/flutter-messenger-go/app/storefront-api/response"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+
 	"github.com/gorilla/websocket"
 	"github.com/rs/zerolog/log"
 )
@@ -21,25 +25,17 @@ const (
 	pingPeriod = (pongWait * 9) / 10
 
 	// Maximum message size allowed from peer.
-	maxMessageSize = 512
+	maxMessageSize = 4096
 )
 
-var (
-	newline = []byte{'\n'}
-	space   = []byte{' '}
-)
+// Close frame sent to clients whose access token was revoked
+var revokedCloseMessage = websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "Access token revoked")
 
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
 }
 
-type Response struct {
-	Status        string `json:"status"`
-	StatusCode    int    `json:"statusCode"`
-	StatusMessage string `json:"statusMessage,omitempty"`
-}
-
 // Client is a middleman between the websocket connection and the hub
 type Client struct {
 	hub *Hub
@@ -47,14 +43,41 @@ type Client struct {
 	// Websocket connection
 	conn *websocket.Conn
 
-	// Buffered channel of outbound messages
-	send chan []byte
+	// Buffered channel of outbound frames
+	send chan outbound
 
 	// Hold user ID for the client
 	userId string
+
+	// ID of the access token the client connected with, its connection is
+	// closed once the token is revoked
+	tokenId string
+
+	// Whether the hub closed the client because its token was revoked, set
+	// before its send buffer is closed
+	revoked bool
+
+	// Whether the user reported being away on this client, only used by the hub
+	away bool
+
+	// Users blocked by the user when the client connected, only used by the hub
+	blocked map[string]bool
+
+	// Context of the storefront calls made for the client, cancelled once the
+	// connection is closed
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
-// readPump pumps messages from the websocket connection to the hub.
+// outbound is an encoded frame waiting to be written to the connection
+type outbound struct {
+	data []byte
+
+	// Message addressed to the client's user, reported as delivered once written
+	message *Frame
+}
+
+// readPump pumps frames from the websocket connection to the hub.
 //
 // The application runs readPump in a per-connection goroutine. The application
 // ensures that there is at most one reader on a connection by executing all
@@ -63,6 +86,7 @@ func (c *Client) ReadPump() {
 	defer func() {
 		c.hub.unregister <- c
 		c.conn.Close()
+		c.cancel()
 	}()
 	c.conn.SetReadLimit(maxMessageSize)
 	c.conn.SetReadDeadline(time.Now().Add(pongWait))
@@ -75,8 +99,7 @@ func (c *Client) ReadPump() {
 			}
 			break
 		}
-		message = bytes.TrimSpace(bytes.Replace(message, newline, space, -1))
-		c.hub.broadcast <- message
+		c.handle(bytes.TrimSpace(message))
 	}
 }
 
@@ -97,27 +120,28 @@ func (c *Client) WritePump() {
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if !ok {
 				// The hub closed the channel.
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				if c.revoked {
+					c.conn.WriteMessage(websocket.CloseMessage, revokedCloseMessage)
+				} else {
+					c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				}
 				return
 			}
 
-			w, err := c.conn.NextWriter(websocket.TextMessage)
-			if err != nil {
+			// Each frame is written as its own websocket message.
+			if err := c.conn.WriteMessage(websocket.TextMessage, message.data); err != nil {
 				return
 			}
-			w.Write(message)
-
-			// Add queued chat messages to the current websocket message.
-			n := len(c.send)
-			for i := 0; i < n; i++ {
-				w.Write(newline)
-				w.Write(<-c.send)
-			}
-
-			if err := w.Close(); err != nil {
+			c.written(message)
+		case <-ticker.C:
+			// Tokens revoked through another server are only noticed here
+			if errors.Is(c.hub.store.AccessTokenExists(c.ctx, c.tokenId), storefront.ErrTokenNotFound) {
+				log.Info().Msgf("[/ws] Access token of %s was revoked, closing connection", c.userId)
+				c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+				c.conn.WriteMessage(websocket.CloseMessage, revokedCloseMessage)
 				return
 			}
-		case <-ticker.C:
+
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
@@ -126,17 +150,18 @@ func (c *Client) WritePump() {
 	}
 }
 
-func ServeWs(hub *Hub, w http.ResponseWriter, r *http.Request, id string) {
+func ServeWs(hub *Hub, w http.ResponseWriter, r *http.Request, id string, tokenID string) {
 	if id == "" {
 		log.Error().Msgf("[GET /ws] Invalid user id %s", id)
-		res := Response{
-			Status:        "INTERNAL SERVER ERROR",
-			StatusCode:    500,
-			StatusMessage: "Unable to parse user from token",
-		}
+		response.Error(w, r, http.StatusInternalServerError, "Unable to parse user from token")
+		return
+	}
 
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(&res)
+	// Load the user's block list before the hub routes anything to them
+	blocked, err := hub.store.GetBlockedUsers(r.Context(), id)
+	if err != nil {
+		log.Error().Msgf("[GET /ws] Error retrieving blocked users for %s, %s", id, err.Error())
+		response.Error(w, r, http.StatusInternalServerError, "Unable to retrieve blocked users")
 		return
 	}
 
@@ -147,18 +172,17 @@ func ServeWs(hub *Hub, w http.ResponseWriter, r *http.Request, id string) {
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Error().Msgf("[GET /ws] Error upgrading to WebSocket connection for %s", id)
-		res := Response{
-			Status:        "INTERNAL SERVER ERROR",
-			StatusCode:    500,
-			StatusMessage: "Unable to parse user from token",
-		}
-
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(&res)
+		response.Error(w, r, http.StatusInternalServerError, "Unable to parse user from token")
 		return
 	}
 
-	client := &Client{hub: hub, conn: conn, send: make(chan []byte, 256), userId: id}
+	// The connection outlives the request, so the client gets its own context
+	ctx, cancel := context.WithCancel(context.Background())
+
+	client := &Client{hub: hub, conn: conn, send: make(chan outbound, 256), userId: id, tokenId: tokenID, blocked: make(map[string]bool), ctx: ctx, cancel: cancel}
+	for _, user := range blocked {
+		client.blocked[user.Id] = true
+	}
 	client.hub.register <- client
 
 	// Allow collection of memory referenced by the caller by doing all work in
@@ -166,5 +190,8 @@ func ServeWs(hub *Hub, w http.ResponseWriter, r *http.Request, id string) {
 	go client.WritePump()
 	go client.ReadPump()
 
+	// Flush messages received while the user was offline
+	go client.replay()
+
 	log.Info().Msgf("[GET /ws] Established WebSocket connection for %s", id)
 }
@@ -0,0 +1,46 @@
+package ws
+
+import (
+	"fmt"
+	"time"
+)
+
+// Policies deciding who a user can send private messages to
+const (
+	PolicyFriends          = "friends"
+	PolicyFriendsOfFriends = "friends_of_friends"
+	PolicyAnyone           = "anyone"
+)
+
+// Who can be messaged under each policy, as told to refused senders
+var policyRecipients = map[string]string{
+	PolicyFriends:          "friends",
+	PolicyFriendsOfFriends: "friends and friends of friends",
+	PolicyAnyone:           "anyone",
+}
+
+// Time friend lists are cached for when no TTL is configured
+const defaultFriendsTTL = time.Minute
+
+// Config for the WebSocket hub.
+type Config struct {
+	// Who users can send private messages to, friends only when empty
+	MessagingPolicy string
+
+	// How long the hub caches a user's friends for
+	FriendsTTL time.Duration
+}
+
+func validateConfig(cfg Config) error {
+	switch cfg.MessagingPolicy {
+	case "", PolicyFriends, PolicyFriendsOfFriends, PolicyAnyone:
+	default:
+		return fmt.Errorf("unknown messaging policy %q", cfg.MessagingPolicy)
+	}
+
+	if cfg.FriendsTTL < 0 {
+		return fmt.Errorf("negative friends TTL")
+	}
+
+	return nil
+}
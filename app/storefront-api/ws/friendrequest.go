@@ -0,0 +1,22 @@
+package ws
+
+import (
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+)
+
+// Notify a user's connected clients of a change to a friend request they
+// sent or received
+func (h *Hub) NotifyFriendRequest(userID string, request dtos.FriendRequest, state string) {
+	h.deliver <- &delivery{
+		userId: userID,
+		frame: Frame{
+			Version:   ProtocolVersion,
+			Type:      TypeFriendRequest,
+			RequestId: request.Id,
+			From:      request.From,
+			To:        request.To,
+			State:     state,
+			Timestamp: request.Timestamp,
+		},
+	}
+}
@@ -0,0 +1,28 @@
+package ws
+
+import (
+	"testing"
+
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+
+	mockstore "github.com/anthonydip/flutter-messenger-go/internal/storefront/mock"
+)
+
+func TestNotifyFriendRequest(t *testing.T) {
+	t.Parallel()
+
+	hub := newTestHub(t, mockstore.New())
+	go hub.Run()
+
+	bobPhone := newTestClient(t, hub, "bob")
+	bobWeb := newTestClient(t, hub, "bob")
+
+	hub.NotifyFriendRequest("bob", dtos.FriendRequest{Id: "alice_bob", From: "alice", To: "bob", Timestamp: 1700000000000}, StateRequested)
+
+	for _, client := range []*Client{bobPhone, bobWeb} {
+		frame := nextFrame(t, client)
+		if frame.Type != TypeFriendRequest || frame.RequestId != "alice_bob" || frame.State != StateRequested {
+			t.Fatalf("expected every device to be notified of the friend request but got %+v", frame)
+		}
+	}
+}
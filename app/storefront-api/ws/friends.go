@@ -0,0 +1,103 @@
+package ws
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+)
+
+// friends caches the friends of users, so that the messaging policy can be
+// enforced without reading the storefront for every message
+type friends struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	store storefront.Storefront
+	users map[string]friendList
+}
+
+// friendList is the set of friend ids of a user as of when it was loaded
+type friendList struct {
+	ids     map[string]bool
+	expires time.Time
+}
+
+// Get the friend ids of a user, loading them if they are not cached
+func (f *friends) get(ctx context.Context, userID string) (map[string]bool, error) {
+	f.mu.Lock()
+	list, ok := f.users[userID]
+	f.mu.Unlock()
+
+	if ok && time.Now().Before(list.expires) {
+		return list.ids, nil
+	}
+
+	friends, err := f.store.GetAllFriends(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	list = friendList{
+		ids:     make(map[string]bool, len(friends)),
+		expires: time.Now().Add(f.ttl),
+	}
+	for _, friend := range friends {
+		list.ids[friend.Id] = true
+	}
+
+	f.mu.Lock()
+	f.users[userID] = list
+	f.mu.Unlock()
+
+	return list.ids, nil
+}
+
+// Drop the cached friends of users whose friendships changed
+func (f *friends) invalidate(userIDs ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, userID := range userIDs {
+		delete(f.users, userID)
+	}
+}
+
+// Tell the hub that friendships of the users changed
+func (h *Hub) InvalidateFriends(userIDs ...string) {
+	h.friends.invalidate(userIDs...)
+}
+
+// Check if the hub's messaging policy lets a user send private messages to
+// another user, or add them to a group
+func (h *Hub) CanMessage(ctx context.Context, from string, to string) (bool, error) {
+	if h.policy == PolicyAnyone || from == to {
+		return true, nil
+	}
+
+	senderFriends, err := h.friends.get(ctx, from)
+	if err != nil {
+		return false, err
+	}
+
+	if senderFriends[to] {
+		return true, nil
+	}
+
+	if h.policy != PolicyFriendsOfFriends {
+		return false, nil
+	}
+
+	recipientFriends, err := h.friends.get(ctx, to)
+	if err != nil {
+		return false, err
+	}
+
+	for id := range senderFriends {
+		if recipientFriends[id] {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
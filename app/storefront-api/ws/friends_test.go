@@ -0,0 +1,70 @@
+package ws
+
+import (
+	"testing"
+
+	mockstore "github.com/anthonydip/flutter-messenger-go/internal/storefront/mock"
+)
+
+func TestMessagingPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		policy       string
+		to           string
+		expectedType string
+	}{
+		// Every user of the storefront mock has the mock user as their only friend
+		"friends to friend": {
+			policy:       PolicyFriends,
+			to:           "8ae84a23-fa49-45eb-8000-bdc9b9fe074a",
+			expectedType: TypeAck,
+		},
+		"friends to stranger": {
+			policy:       PolicyFriends,
+			to:           "bob",
+			expectedType: TypeError,
+		},
+		"friends of friends to friend of friend": {
+			policy:       PolicyFriendsOfFriends,
+			to:           "bob",
+			expectedType: TypeAck,
+		},
+		"anyone to stranger": {
+			policy:       PolicyAnyone,
+			to:           "bob",
+			expectedType: TypeAck,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			hub, err := NewHub(Config{MessagingPolicy: test.policy}, mockstore.New())
+			if err != nil {
+				t.Fatalf("couldn't create test hub: %s", err.Error())
+			}
+			go hub.Run()
+
+			alice := newTestClient(t, hub, "alice")
+			alice.handle([]byte(`{"v": 1, "type": "message", "id": "c-1", "to": "` + test.to + `", "body": "hello"}`))
+
+			reply := nextFrame(t, alice)
+			if reply.Type != test.expectedType {
+				t.Fatalf("expected a %s frame but got %+v", test.expectedType, reply)
+			}
+
+			if test.expectedType == TypeError && reply.Error.Code != ErrForbidden {
+				t.Fatalf("expected error code %s but got %s", ErrForbidden, reply.Error.Code)
+			}
+		})
+	}
+}
+
+func TestNewHubInvalidPolicy(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewHub(Config{MessagingPolicy: "everyone"}, mockstore.New())
+	if err == nil {
+		t.Fatalf("expected an unknown messaging policy to be rejected")
+	}
+}
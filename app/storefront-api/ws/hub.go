@@ -1,19 +1,24 @@
 package ws
 
 import (
+	"encoding/json"
 	"fmt"
-	"strings"
 	"time"
+
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+
+	"github.com/rs/zerolog/log"
 )
 
-// Hub maintains the set of active clients and broadcasts messages to the
+// Hub maintains the set of active clients and routes frames between the
 // clients.
 type Hub struct {
 	// Registered clients.
 	clients map[*Client]bool
 
-	// Inbound messages from the clients.
-	broadcast chan []byte
+	// Frames to route to connected clients.
+	deliver chan *delivery
 
 	// Register requests from the clients.
 	register chan *Client
@@ -21,18 +26,92 @@ type Hub struct {
 	// Unregister requests from clients.
 	unregister chan *Client
 
-	// Mapping of user id to clients
-	userIds map[string]*Client
+	// Clients reporting whether their user is away.
+	status chan clientStatus
+
+	// Activity frames to track and route to connected clients.
+	activity chan Frame
+
+	// Activities that expired without being stopped.
+	expire chan *activityTimer
+
+	// Users blocking or unblocking other users.
+	block chan blockUpdate
+
+	// Access tokens revoked, whose clients are closed.
+	revoke chan string
+
+	// Activities in progress and how long they last without being started again
+	activities      map[activityKey]*activityTimer
+	activityTimeout time.Duration
+
+	// Mapping of user id to the user's connected clients, one per device
+	userIds map[string]map[*Client]bool
+
+	// Mapping of connected user id to the users they blocked
+	blocked map[string]map[string]bool
+
+	// Presence of the connected users
+	presence *presence
+
+	// Who users can send private messages to
+	policy string
+
+	// Cached friends of users, used to enforce the messaging policy
+	friends *friends
+
+	// Storage for routed messages
+	store storefront.Storefront
+}
+
+// delivery is a frame addressed either to a single client or to a user
+type delivery struct {
+	// Client to send the frame to, used for replies to the sender
+	client *Client
+
+	// User to send the frame to, on every device, when client is not set
+	userId string
+
+	// Client of the user that should not receive the frame
+	except *Client
+
+	frame Frame
 }
 
-func NewHub() *Hub {
+// Initialize a new hub
+func NewHub(cfg Config, store storefront.Storefront) (*Hub, error) {
+	if err := validateConfig(cfg); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if cfg.MessagingPolicy == "" {
+		cfg.MessagingPolicy = PolicyFriends
+	}
+
+	if cfg.FriendsTTL == 0 {
+		cfg.FriendsTTL = defaultFriendsTTL
+	}
+
 	return &Hub{
-		broadcast:  make(chan []byte),
+		store:      store,
+		deliver:    make(chan *delivery),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
+		status:     make(chan clientStatus),
+		activity:   make(chan Frame),
+		expire:     make(chan *activityTimer),
+		block:      make(chan blockUpdate),
+		revoke:     make(chan string),
 		clients:    make(map[*Client]bool),
-		userIds:    make(map[string]*Client),
-	}
+		userIds:    make(map[string]map[*Client]bool),
+		blocked:    make(map[string]map[string]bool),
+		presence:   &presence{users: make(map[string]dtos.Presence)},
+		policy:     cfg.MessagingPolicy,
+		friends:    &friends{ttl: cfg.FriendsTTL, store: store, users: make(map[string]friendList)},
+
+		activities:      make(map[activityKey]*activityTimer),
+		activityTimeout: defaultActivityTimeout,
+	}, nil
 }
 
 func (h *Hub) Run() {
@@ -41,47 +120,109 @@ func (h *Hub) Run() {
 		// When a new client registers with the hub
 		case client := <-h.register:
 			h.clients[client] = true
-			h.userIds[client.userId] = client
+			if _, ok := h.userIds[client.userId]; !ok {
+				h.userIds[client.userId] = make(map[*Client]bool)
+			}
+			h.userIds[client.userId][client] = true
+			if _, ok := h.blocked[client.userId]; !ok {
+				h.blocked[client.userId] = make(map[string]bool)
+				for blockedId := range client.blocked {
+					h.blocked[client.userId][blockedId] = true
+				}
+			}
+			h.updatePresence(client.userId)
+		// When a client reports whether its user is away
+		case s := <-h.status:
+			if _, ok := h.clients[s.client]; ok {
+				s.client.away = s.away
+				h.updatePresence(s.client.userId)
+			}
 		// When a client requests to unregister (disconnects)
 		case client := <-h.unregister:
 			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
-				close(client.send)
-			}
-		// When a message is broadcasted to all connected clients
-		case message := <-h.broadcast:
-			// Check if the message is a private message
-			// Private message is of the form "/msg <sender id> <recipient id> <message> <timestamp>"
-			if strings.HasPrefix(string(message), "/msg ") {
-				// Extract the user id from the message
-				parts := strings.SplitN(string(message[5:]), " ", 3)
-				targetId := parts[1]
-
-				// Create the timestamp for the message
-				currentTime := time.Now()
-				timestamp := fmt.Sprintf("%d-%d-%d %d:%d:%d:%d",
-					currentTime.Year(),
-					currentTime.Month(),
-					currentTime.Day(),
-					currentTime.Hour(),
-					currentTime.Minute(),
-					currentTime.Second(),
-					currentTime.Nanosecond(),
-				)
-
-				newMessage := fmt.Sprintf("%s %s", message, timestamp)
-
-				// Find the target client and send the private messsage
-				if targetId, ok := h.userIds[targetId]; ok {
-					select {
-					case targetId.send <- []byte(newMessage[5:]):
-					default:
-						close(targetId.send)
-						delete(h.clients, targetId)
-						delete(h.userIds, targetId.userId)
-					}
-				}
+				h.remove(client)
 			}
+		// When a frame is routed to a client or user
+		case d := <-h.deliver:
+			h.route(d)
+		// When a client starts or stops an activity
+		case frame := <-h.activity:
+			h.trackActivity(frame)
+			h.route(&delivery{userId: frame.To, frame: frame})
+		// When an activity was never stopped by its client
+		case a := <-h.expire:
+			h.expireActivity(a)
+		// When a user blocks or unblocks another user
+		case b := <-h.block:
+			h.updateBlock(b)
+		// When an access token is revoked
+		case tokenID := <-h.revoke:
+			h.revokeToken(tokenID)
+		}
+	}
+}
+
+// Send a frame to the client or to every client of the user it is addressed to
+func (h *Hub) route(d *delivery) {
+	if d.client != nil {
+		h.send(d.client, d.frame)
+		return
+	}
+
+	// Presence is pushed asynchronously, drop updates overtaken by a newer one
+	if d.frame.Type == TypePresence && !h.isCurrentPresence(d.frame) {
+		return
+	}
+
+	// Nothing from a user reaches a user who blocked them, or whom they blocked
+	if d.frame.From != "" && d.frame.From != d.userId && h.isBlocked(d.userId, d.frame.From) {
+		return
+	}
+
+	for target := range h.userIds[d.userId] {
+		if target != d.except {
+			h.send(target, d.frame)
 		}
 	}
 }
+
+// Encode and queue a frame on the client's send buffer, dropping the client
+// if it cannot keep up
+func (h *Hub) send(client *Client, frame Frame) bool {
+	if _, ok := h.clients[client]; !ok {
+		return false
+	}
+
+	data, err := json.Marshal(frame)
+	if err != nil {
+		log.Error().Err(err).Msgf("[/ws] Error encoding %s frame for %s", frame.Type, client.userId)
+		return false
+	}
+
+	o := outbound{data: data}
+	if frame.Type == TypeMessage && frame.To == client.userId {
+		o.message = &frame
+	}
+
+	select {
+	case client.send <- o:
+		return true
+	default:
+		h.remove(client)
+		return false
+	}
+}
+
+// Remove a client from the hub and close its send buffer
+func (h *Hub) remove(client *Client) {
+	delete(h.clients, client)
+	close(client.send)
+
+	delete(h.userIds[client.userId], client)
+	if len(h.userIds[client.userId]) == 0 {
+		delete(h.userIds, client.userId)
+		delete(h.blocked, client.userId)
+	}
+
+	h.updatePresence(client.userId)
+}
@@ -0,0 +1,122 @@
+package ws
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+
+	"github.com/rs/zerolog/log"
+)
+
+// presence holds the presence of connected users so it can be read outside
+// of the hub's goroutine
+type presence struct {
+	mu    sync.RWMutex
+	users map[string]dtos.Presence
+}
+
+// clientStatus is a client reporting whether its user is away
+type clientStatus struct {
+	client *Client
+	away   bool
+}
+
+// Presence returns the presence of a connected user, ok is false if the user
+// has no connected clients
+func (h *Hub) Presence(userID string) (p dtos.Presence, ok bool) {
+	h.presence.mu.RLock()
+	defer h.presence.mu.RUnlock()
+
+	p, ok = h.presence.users[userID]
+	return p, ok
+}
+
+// Handle the client reporting whether its user is online or away
+func (c *Client) handlePresence(frame Frame) {
+	if frame.State != dtos.PresenceOnline && frame.State != dtos.PresenceAway {
+		c.reply(errorFrame(frame.Id, ErrInvalidFrame, "Presence state must be online or away"))
+		return
+	}
+
+	c.hub.status <- clientStatus{client: c, away: frame.State == dtos.PresenceAway}
+}
+
+// Recompute the presence of a user from their clients, storing and pushing it
+// to their friends when it changes. A user is online if any of their clients
+// is not away.
+func (h *Hub) updatePresence(userID string) {
+	status := dtos.PresenceOffline
+	for client := range h.userIds[userID] {
+		if !client.away {
+			status = dtos.PresenceOnline
+			break
+		}
+		status = dtos.PresenceAway
+	}
+
+	h.presence.mu.Lock()
+	current, ok := h.presence.users[userID]
+	if (ok && current.Status == status) || (!ok && status == dtos.PresenceOffline) {
+		h.presence.mu.Unlock()
+		return
+	}
+
+	p := dtos.Presence{Id: userID, Status: status, LastSeen: time.Now().UnixMilli()}
+	if status == dtos.PresenceOffline {
+		delete(h.presence.users, userID)
+	} else {
+		h.presence.users[userID] = p
+	}
+	h.presence.mu.Unlock()
+
+	go h.pushPresence(p)
+}
+
+// Check a presence frame still describes the user's presence, only called
+// from the hub's goroutine which is the only one updating it
+func (h *Hub) isCurrentPresence(frame Frame) bool {
+	p, ok := h.presence.users[frame.From]
+	if !ok {
+		return frame.State == dtos.PresenceOffline
+	}
+
+	return p.Status == frame.State && p.LastSeen == frame.LastSeen
+}
+
+// Store the presence of a user going offline and send it to the user's friends.
+// The user's clients may be gone already, so this isn't tied to any of them.
+func (h *Hub) pushPresence(p dtos.Presence) {
+	ctx := context.Background()
+
+	if p.Status == dtos.PresenceOffline {
+		err := h.store.SavePresence(ctx, p)
+		if err != nil {
+			log.Error().Msgf("[/ws] Error saving presence of %s, %s", p.Id, err.Error())
+		}
+	}
+
+	friends, err := h.store.GetAllFriends(ctx, p.Id)
+	if err != nil {
+		log.Error().Msgf("[/ws] Error retrieving friends of %s, %s", p.Id, err.Error())
+		return
+	}
+
+	for _, friend := range friends {
+		if friend.Id == p.Id {
+			continue
+		}
+
+		h.deliver <- &delivery{
+			userId: friend.Id,
+			frame: Frame{
+				Version:  ProtocolVersion,
+				Type:     TypePresence,
+				From:     p.Id,
+				State:    p.Status,
+				LastSeen: p.LastSeen,
+			},
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package ws
+
+import (
+	"testing"
+
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+
+	mockstore "github.com/anthonydip/flutter-messenger-go/internal/storefront/mock"
+)
+
+func TestPresence(t *testing.T) {
+	t.Parallel()
+
+	hub := newTestHub(t, mockstore.New())
+	go hub.Run()
+
+	// The storefront mock's only friend
+	friend := newTestClient(t, hub, "8ae84a23-fa49-45eb-8000-bdc9b9fe074a")
+
+	alicePhone := newTestClient(t, hub, "alice")
+	expectPresence(t, friend, "alice", dtos.PresenceOnline)
+
+	aliceWeb := newTestClient(t, hub, "alice")
+	alicePhone.handle([]byte(`{"v": 1, "type": "presence", "state": "away"}`))
+	aliceWeb.handle([]byte(`{"v": 1, "type": "presence", "state": "away"}`))
+	expectPresence(t, friend, "alice", dtos.PresenceAway)
+
+	if p, ok := hub.Presence("alice"); !ok || p.Status != dtos.PresenceAway {
+		t.Fatalf("expected alice to be away but got %+v", p)
+	}
+
+	hub.unregister <- alicePhone
+	hub.unregister <- aliceWeb
+	expectPresence(t, friend, "alice", dtos.PresenceOffline)
+
+	if p, ok := hub.Presence("alice"); ok {
+		t.Fatalf("expected alice to be offline but got %+v", p)
+	}
+}
+
+// Read the next frame of the client, expecting a presence update of the user
+func expectPresence(t *testing.T, client *Client, userID string, status string) {
+	t.Helper()
+
+	frame := nextFrame(t, client)
+	if frame.Type != TypePresence || frame.From != userID || frame.State != status || frame.LastSeen == 0 {
+		t.Fatalf("expected %s to be %s but got %+v", userID, status, frame)
+	}
+}
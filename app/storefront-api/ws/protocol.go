@@ -0,0 +1,393 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// Version of the WebSocket wire protocol spoken by the hub
+const ProtocolVersion = 1
+
+// Frame types, some of which are sent by both clients and the server
+const (
+	TypeMessage   = "message"
+	TypeAck       = "ack"
+	TypeError     = "error"
+	TypeDelivered = "delivered"
+	TypeRead      = "read"
+	TypeActivity  = "activity"
+	TypePresence  = "presence"
+
+	TypeFriendRequest = "friend_request"
+)
+
+// Ephemeral activities reported by activity frames
+const (
+	ActivityTyping    = "typing"
+	ActivityRecording = "recording"
+)
+
+// States of an activity
+const (
+	StateStarted = "started"
+	StateStopped = "stopped"
+)
+
+// Changes to a friend request reported by friend request frames
+const (
+	StateRequested = "requested"
+	StateAccepted  = "accepted"
+	StateCancelled = "cancelled"
+)
+
+// Error codes carried by error frames
+const (
+	ErrMalformedFrame     = "malformed_frame"
+	ErrUnsupportedVersion = "unsupported_version"
+	ErrUnknownType        = "unknown_type"
+	ErrInvalidMessage     = "invalid_message"
+	ErrInvalidFrame       = "invalid_frame"
+	ErrSenderMismatch     = "sender_mismatch"
+	ErrForbidden          = "forbidden"
+	ErrInternal           = "internal_error"
+)
+
+// Frame is the JSON envelope of every WebSocket message, in both directions
+type Frame struct {
+	Version         int         `json:"v"`
+	Type            string      `json:"type"`
+	Id              string      `json:"id,omitempty"`
+	MessageId       string      `json:"messageId,omitempty"`
+	RequestId       string      `json:"requestId,omitempty"`
+	From            string      `json:"from,omitempty"`
+	To              string      `json:"to,omitempty"`
+	Group           string      `json:"group,omitempty"`
+	Body            string      `json:"body,omitempty"`
+	Activity        string      `json:"activity,omitempty"`
+	State           string      `json:"state,omitempty"`
+	LastSeen        int64       `json:"lastSeen,omitempty"`
+	Timestamp       int64       `json:"timestamp,omitempty"`
+	ServerTimestamp int64       `json:"serverTimestamp,omitempty"`
+	Error           *FrameError `json:"error,omitempty"`
+}
+
+// FrameError describes why a client frame was rejected
+type FrameError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Build an error frame in reply to the client frame with the given id
+func errorFrame(id string, code string, message string) Frame {
+	return Frame{
+		Version: ProtocolVersion,
+		Type:    TypeError,
+		Id:      id,
+		Error: &FrameError{
+			Code:    code,
+			Message: message,
+		},
+	}
+}
+
+// Handle a raw frame read from the client's connection
+func (c *Client) handle(data []byte) {
+	frame := Frame{}
+
+	if err := json.Unmarshal(data, &frame); err != nil {
+		c.reply(errorFrame("", ErrMalformedFrame, "Frame is not a valid JSON object"))
+		return
+	}
+
+	if frame.Version != ProtocolVersion {
+		c.reply(errorFrame(frame.Id, ErrUnsupportedVersion, fmt.Sprintf("Unsupported protocol version %d", frame.Version)))
+		return
+	}
+
+	switch frame.Type {
+	case TypeMessage:
+		c.handleMessage(frame)
+	case TypeAck:
+		c.handleAck(frame)
+	case TypeRead:
+		c.handleRead(frame)
+	case TypeActivity:
+		c.handleActivity(frame)
+	case TypePresence:
+		c.handlePresence(frame)
+	default:
+		c.reply(errorFrame(frame.Id, ErrUnknownType, fmt.Sprintf("Unknown frame type %q", frame.Type)))
+	}
+}
+
+// Handle a private or group message sent by the client
+func (c *Client) handleMessage(frame Frame) {
+	if frame.Id == "" || frame.Body == "" || (frame.To == "") == (frame.Group == "") {
+		c.reply(errorFrame(frame.Id, ErrInvalidMessage, "Message requires an id, a body and either a recipient or a group"))
+		return
+	}
+
+	// The sender is always the user the connection was authenticated as
+	if frame.From != "" && frame.From != c.userId {
+		log.Warn().Msgf("[/ws] User %s attempted to send a message as %s", c.userId, frame.From)
+		c.reply(errorFrame(frame.Id, ErrSenderMismatch, "Messages can only be sent as the authenticated user"))
+		return
+	}
+
+	message := dtos.Message{
+		Id:              uuid.New().String(),
+		From:            c.userId,
+		Body:            frame.Body,
+		Timestamp:       frame.Timestamp,
+		ServerTimestamp: time.Now().UnixMilli(),
+		Status:          dtos.MessageSent,
+	}
+
+	recipients := []string{frame.To}
+
+	if frame.Group != "" {
+		group, err := c.hub.store.GetGroup(c.ctx, frame.Group)
+		if err != nil && !errors.Is(err, storefront.ErrGroupNotFound) {
+			log.Error().Msgf("[/ws] Error retrieving group %s, %s", frame.Group, err.Error())
+			c.reply(errorFrame(frame.Id, ErrInternal, "Unable to send message"))
+			return
+		}
+
+		// Groups the sender is not a member of are treated as not existing
+		if _, ok := group.Members[c.userId]; !ok {
+			c.reply(errorFrame(frame.Id, ErrForbidden, "Messages can only be sent to groups the user is a member of"))
+			return
+		}
+
+		recipients = make([]string, 0, len(group.Members))
+		for member := range group.Members {
+			if member == c.userId {
+				continue
+			}
+
+			// Members who blocked the sender, or whom the sender blocked, do not
+			// receive their messages
+			blocked, err := c.blockedWith(member)
+			if err != nil {
+				log.Error().Msgf("[/ws] Error checking block lists of %s and %s, %s", c.userId, member, err.Error())
+				c.reply(errorFrame(frame.Id, ErrInternal, "Unable to send message"))
+				return
+			}
+
+			if !blocked {
+				recipients = append(recipients, member)
+			}
+		}
+
+		message.ConversationId = group.Id
+		message.GroupId = group.Id
+	} else {
+		blocked, err := c.blockedWith(frame.To)
+		if err != nil {
+			log.Error().Msgf("[/ws] Error checking block lists of %s and %s, %s", c.userId, frame.To, err.Error())
+			c.reply(errorFrame(frame.Id, ErrInternal, "Unable to send message"))
+			return
+		}
+
+		if blocked {
+			c.reply(errorFrame(frame.Id, ErrForbidden, "Messages can not be sent to this user"))
+			return
+		}
+
+		allowed, err := c.hub.CanMessage(c.ctx, c.userId, frame.To)
+		if err != nil {
+			log.Error().Msgf("[/ws] Error checking if %s can message %s, %s", c.userId, frame.To, err.Error())
+			c.reply(errorFrame(frame.Id, ErrInternal, "Unable to send message"))
+			return
+		}
+
+		if !allowed {
+			c.reply(errorFrame(frame.Id, ErrForbidden, fmt.Sprintf("Messages can only be sent to %s", policyRecipients[c.hub.policy])))
+			return
+		}
+
+		message.ConversationId = storefront.ConversationID(c.userId, frame.To)
+		message.To = frame.To
+	}
+
+	// Persist the message before it is delivered
+	err := c.hub.store.SaveMessage(c.ctx, message)
+	if err != nil {
+		log.Error().Msgf("[/ws] Error saving message from %s, %s", c.userId, err.Error())
+		c.reply(errorFrame(frame.Id, ErrInternal, "Unable to save message"))
+		return
+	}
+
+	// Keep the message queued for each recipient until one of their devices
+	// acknowledges it
+	for _, recipient := range recipients {
+		err = c.hub.store.QueueMessage(c.ctx, recipient, message)
+		if err != nil {
+			log.Error().Msgf("[/ws] Error queueing message %s for %s, %s", message.Id, recipient, err.Error())
+		}
+	}
+
+	c.reply(Frame{
+		Version:         ProtocolVersion,
+		Type:            TypeAck,
+		Id:              frame.Id,
+		MessageId:       message.Id,
+		ServerTimestamp: message.ServerTimestamp,
+	})
+
+	for _, recipient := range recipients {
+		c.hub.deliver <- &delivery{
+			userId: recipient,
+			frame:  messageFrame(message),
+		}
+	}
+
+	// Keep the conversation in sync on the sender's other devices
+	if message.To != c.userId {
+		c.hub.deliver <- &delivery{
+			userId: c.userId,
+			except: c,
+			frame:  messageFrame(message),
+		}
+	}
+}
+
+// Handle the client acknowledging receipt of a message
+func (c *Client) handleAck(frame Frame) {
+	if frame.MessageId == "" {
+		c.reply(errorFrame(frame.Id, ErrInvalidFrame, "Ack requires a messageId"))
+		return
+	}
+
+	err := c.hub.store.DeleteQueuedMessage(c.ctx, c.userId, frame.MessageId)
+	if err != nil {
+		log.Error().Msgf("[/ws] Error removing message %s from the queue of %s, %s", frame.MessageId, c.userId, err.Error())
+		c.reply(errorFrame(frame.Id, ErrInternal, "Unable to acknowledge message"))
+	}
+}
+
+// Handle the client reporting that its user read a message they received
+func (c *Client) handleRead(frame Frame) {
+	if frame.MessageId == "" || frame.To == "" {
+		c.reply(errorFrame(frame.Id, ErrInvalidFrame, "Read requires a messageId and the sender it is sent to"))
+		return
+	}
+
+	message, err := c.hub.store.GetMessage(c.ctx, storefront.ConversationID(c.userId, frame.To), frame.MessageId)
+	if err != nil {
+		if errors.Is(err, storefront.ErrMessageNotFound) {
+			c.reply(errorFrame(frame.Id, ErrInvalidFrame, "Message does not exist"))
+			return
+		}
+		log.Error().Msgf("[/ws] Error retrieving message %s, %s", frame.MessageId, err.Error())
+		c.reply(errorFrame(frame.Id, ErrInternal, "Unable to mark message as read"))
+		return
+	}
+
+	// Only the recipient of a message can read it
+	if message.To != c.userId {
+		c.reply(errorFrame(frame.Id, ErrInvalidFrame, "Message was not sent to the user"))
+		return
+	}
+
+	updated, err := c.hub.store.UpdateMessageStatus(c.ctx, message.ConversationId, message.Id, dtos.MessageRead)
+	if err != nil {
+		log.Error().Msgf("[/ws] Error marking message %s as read, %s", message.Id, err.Error())
+		c.reply(errorFrame(frame.Id, ErrInternal, "Unable to mark message as read"))
+		return
+	}
+
+	if !updated {
+		return
+	}
+
+	receipt := Frame{
+		Version:   ProtocolVersion,
+		Type:      TypeRead,
+		MessageId: message.Id,
+		From:      message.From,
+		To:        message.To,
+	}
+
+	// Relay the receipt to the sender and to the reader's other devices
+	c.hub.deliver <- &delivery{userId: message.From, frame: receipt}
+	c.hub.deliver <- &delivery{userId: c.userId, except: c, frame: receipt}
+}
+
+// Called once an outbound frame has been written to the connection
+func (c *Client) written(o outbound) {
+	if o.message != nil {
+		go c.delivered(*o.message)
+	}
+}
+
+// Mark a message written to the recipient's connection as delivered and
+// notify its sender
+func (c *Client) delivered(frame Frame) {
+	updated, err := c.hub.store.UpdateMessageStatus(c.ctx, storefront.ConversationID(frame.From, frame.To), frame.MessageId, dtos.MessageDelivered)
+	if err != nil {
+		log.Error().Msgf("[/ws] Error marking message %s as delivered, %s", frame.MessageId, err.Error())
+		return
+	}
+
+	if !updated {
+		return
+	}
+
+	c.hub.deliver <- &delivery{
+		userId: frame.From,
+		frame: Frame{
+			Version:   ProtocolVersion,
+			Type:      TypeDelivered,
+			MessageId: frame.MessageId,
+			From:      frame.From,
+			To:        frame.To,
+		},
+	}
+}
+
+// Send the messages queued while the client's user was offline, oldest first.
+// The queue is the user's rather than the device's, see QueueMessage.
+func (c *Client) replay() {
+	messages, err := c.hub.store.GetQueuedMessages(c.ctx, c.userId)
+	if err != nil {
+		log.Error().Msgf("[/ws] Error retrieving queued messages for %s, %s", c.userId, err.Error())
+		return
+	}
+
+	for _, message := range messages {
+		c.reply(messageFrame(message))
+	}
+
+	if len(messages) > 0 {
+		log.Info().Msgf("[/ws] Replayed %d queued messages for %s", len(messages), c.userId)
+	}
+}
+
+// Build the frame delivering a stored message to its recipient
+func messageFrame(message dtos.Message) Frame {
+	return Frame{
+		Version:         ProtocolVersion,
+		Type:            TypeMessage,
+		MessageId:       message.Id,
+		From:            message.From,
+		To:              message.To,
+		Group:           message.GroupId,
+		Body:            message.Body,
+		Timestamp:       message.Timestamp,
+		ServerTimestamp: message.ServerTimestamp,
+	}
+}
+
+// Send a frame back to this client through the hub
+func (c *Client) reply(frame Frame) {
+	c.hub.deliver <- &delivery{client: c, frame: frame}
+}
@@ -0,0 +1,413 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+
+	mockstore "github.com/anthonydip/flutter-messenger-go/internal/storefront/mock"
+)
+
+// Create a hub that lets anyone message anyone, unless the test is about the
+// messaging policy
+func newTestHub(t *testing.T, store storefront.Storefront) *Hub {
+	t.Helper()
+
+	hub, err := NewHub(Config{MessagingPolicy: PolicyAnyone}, store)
+	if err != nil {
+		t.Fatalf("couldn't create test hub: %s", err.Error())
+	}
+
+	return hub
+}
+
+// Register a connectionless client with the hub
+func newTestClient(t *testing.T, hub *Hub, userId string) *Client {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	client := &Client{hub: hub, send: make(chan outbound, 256), userId: userId, ctx: ctx, cancel: cancel}
+	hub.register <- client
+
+	return client
+}
+
+// Read the next frame queued for the client, as its write pump would
+func nextFrame(t *testing.T, client *Client) Frame {
+	t.Helper()
+
+	select {
+	case o := <-client.send:
+		frame := Frame{}
+		if err := json.Unmarshal(o.data, &frame); err != nil {
+			t.Fatalf("couldn't decode frame %s: %s", o.data, err.Error())
+		}
+		client.written(o)
+		return frame
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for a frame for %s", client.userId)
+	}
+
+	return Frame{}
+}
+
+func TestHandleErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		frame        string
+		expectedCode string
+	}{
+		"malformed": {
+			frame:        `/msg alice bob hello`,
+			expectedCode: ErrMalformedFrame,
+		},
+		"unsupported version": {
+			frame:        `{"v": 2, "type": "message", "id": "1", "to": "bob", "body": "hello"}`,
+			expectedCode: ErrUnsupportedVersion,
+		},
+		"unknown type": {
+			frame:        `{"v": 1, "type": "shout", "id": "1"}`,
+			expectedCode: ErrUnknownType,
+		},
+		"missing recipient": {
+			frame:        `{"v": 1, "type": "message", "id": "1", "body": "hello"}`,
+			expectedCode: ErrInvalidMessage,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			hub := newTestHub(t, mockstore.New())
+			go hub.Run()
+
+			alice := newTestClient(t, hub, "alice")
+			alice.handle([]byte(test.frame))
+
+			frame := nextFrame(t, alice)
+			if frame.Type != TypeError || frame.Error == nil {
+				t.Fatalf("expected an error frame but got %+v", frame)
+			}
+			if frame.Error.Code != test.expectedCode {
+				t.Fatalf("expected error code %s but got %s", test.expectedCode, frame.Error.Code)
+			}
+		})
+	}
+}
+
+func TestHandleMessage(t *testing.T) {
+	t.Parallel()
+
+	hub := newTestHub(t, mockstore.New())
+	go hub.Run()
+
+	alice := newTestClient(t, hub, "alice")
+	bob := newTestClient(t, hub, "bob")
+
+	alice.handle([]byte(`{"v": 1, "type": "message", "id": "c-1", "to": "bob", "body": "hello", "timestamp": 1700000000000}`))
+
+	ack := nextFrame(t, alice)
+	if ack.Type != TypeAck || ack.Id != "c-1" || ack.MessageId == "" {
+		t.Fatalf("expected an ack for c-1 but got %+v", ack)
+	}
+
+	message := nextFrame(t, bob)
+	if message.Type != TypeMessage || message.Body != "hello" || message.MessageId != ack.MessageId {
+		t.Fatalf("expected bob to receive the message but got %+v", message)
+	}
+	if message.Timestamp != 1700000000000 || message.ServerTimestamp == 0 {
+		t.Fatalf("expected client and server timestamps but got %+v", message)
+	}
+
+	delivered := nextFrame(t, alice)
+	if delivered.Type != TypeDelivered || delivered.MessageId != ack.MessageId {
+		t.Fatalf("expected a delivered receipt for %s but got %+v", ack.MessageId, delivered)
+	}
+}
+
+func TestHandleMessageSender(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		from         string
+		expectedType string
+	}{
+		"omitted": {
+			expectedType: TypeAck,
+		},
+		"authenticated user": {
+			from:         "alice",
+			expectedType: TypeAck,
+		},
+		"impersonation": {
+			from:         "mallory",
+			expectedType: TypeError,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			hub := newTestHub(t, mockstore.New())
+			go hub.Run()
+
+			alice := newTestClient(t, hub, "alice")
+			bob := newTestClient(t, hub, "bob")
+
+			data, _ := json.Marshal(Frame{Version: ProtocolVersion, Type: TypeMessage, Id: "c-1", From: test.from, To: "bob", Body: "hello"})
+			alice.handle(data)
+
+			reply := nextFrame(t, alice)
+			if reply.Type != test.expectedType {
+				t.Fatalf("expected a %s frame but got %+v", test.expectedType, reply)
+			}
+
+			if test.expectedType == TypeError {
+				if reply.Error.Code != ErrSenderMismatch {
+					t.Fatalf("expected error code %s but got %s", ErrSenderMismatch, reply.Error.Code)
+				}
+				return
+			}
+
+			message := nextFrame(t, bob)
+			if message.From != "alice" {
+				t.Fatalf("expected the message to be stamped as from alice but got %s", message.From)
+			}
+		})
+	}
+}
+
+func TestHandleMessageSaveFails(t *testing.T) {
+	t.Parallel()
+
+	hub := newTestHub(t, mockstore.New(mockstore.SaveMessageResult(errors.New("unavailable"))))
+	go hub.Run()
+
+	alice := newTestClient(t, hub, "alice")
+	bob := newTestClient(t, hub, "bob")
+
+	alice.handle([]byte(`{"v": 1, "type": "message", "id": "c-1", "to": "bob", "body": "hello"}`))
+
+	reply := nextFrame(t, alice)
+	if reply.Type != TypeError || reply.Error.Code != ErrInternal {
+		t.Fatalf("expected an %s error frame but got %+v", ErrInternal, reply)
+	}
+
+	select {
+	case o := <-bob.send:
+		t.Fatalf("expected no message to be delivered but got %s", o.data)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestReplay(t *testing.T) {
+	t.Parallel()
+
+	hub := newTestHub(t, mockstore.New())
+	go hub.Run()
+
+	bob := newTestClient(t, hub, "bob")
+	bob.replay()
+
+	message := nextFrame(t, bob)
+	if message.Type != TypeMessage || message.To != "bob" || message.MessageId == "" {
+		t.Fatalf("expected bob to receive the queued message but got %+v", message)
+	}
+}
+
+func TestQueueSharedByDevices(t *testing.T) {
+	t.Parallel()
+
+	hub := newTestHub(t, storefront.NewMemory())
+	go hub.Run()
+
+	alice := newTestClient(t, hub, "alice")
+	bobPhone := newTestClient(t, hub, "bob")
+
+	alice.handle([]byte(`{"v": 1, "type": "message", "id": "c-1", "to": "bob", "body": "hello"}`))
+
+	ack := nextFrame(t, alice)
+	if ack.Type != TypeAck {
+		t.Fatalf("expected an ack but got %+v", ack)
+	}
+
+	message := nextFrame(t, bobPhone)
+	if message.MessageId != ack.MessageId {
+		t.Fatalf("expected bob's phone to receive the message but got %+v", message)
+	}
+
+	// A device connecting is replayed the message, though another device
+	// already received it
+	bobTablet := newTestClient(t, hub, "bob")
+	bobTablet.replay()
+
+	replayed := nextFrame(t, bobTablet)
+	if replayed.MessageId != ack.MessageId {
+		t.Fatalf("expected bob's tablet to be replayed the message but got %+v", replayed)
+	}
+
+	// Once a device acknowledges the message, it is no longer replayed to any
+	bobPhone.handle([]byte(`{"v": 1, "type": "ack", "id": "c-2", "messageId": "` + ack.MessageId + `"}`))
+
+	bobLaptop := newTestClient(t, hub, "bob")
+	bobLaptop.replay()
+
+	select {
+	case o := <-bobLaptop.send:
+		t.Fatalf("expected nothing to be replayed but got %s", o.data)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestHandleAck(t *testing.T) {
+	t.Parallel()
+
+	hub := newTestHub(t, mockstore.New())
+	go hub.Run()
+
+	bob := newTestClient(t, hub, "bob")
+	bob.handle([]byte(`{"v": 1, "type": "ack", "id": "c-2"}`))
+
+	reply := nextFrame(t, bob)
+	if reply.Type != TypeError || reply.Error.Code != ErrInvalidFrame {
+		t.Fatalf("expected an %s error frame but got %+v", ErrInvalidFrame, reply)
+	}
+}
+
+func TestHandleMessageMultipleDevices(t *testing.T) {
+	t.Parallel()
+
+	hub := newTestHub(t, mockstore.New())
+	go hub.Run()
+
+	alicePhone := newTestClient(t, hub, "alice")
+	aliceTablet := newTestClient(t, hub, "alice")
+	bobPhone := newTestClient(t, hub, "bob")
+	bobWeb := newTestClient(t, hub, "bob")
+	bobTablet := newTestClient(t, hub, "bob")
+
+	// A disconnected device no longer receives messages
+	hub.unregister <- bobTablet
+
+	alicePhone.handle([]byte(`{"v": 1, "type": "message", "id": "c-1", "to": "bob", "body": "hello"}`))
+
+	ack := nextFrame(t, alicePhone)
+	if ack.Type != TypeAck {
+		t.Fatalf("expected an ack but got %+v", ack)
+	}
+
+	for _, client := range []*Client{bobPhone, bobWeb, aliceTablet} {
+		message := nextFrame(t, client)
+		if message.Type != TypeMessage || message.MessageId != ack.MessageId {
+			t.Fatalf("expected every device to receive the message but got %+v", message)
+		}
+	}
+
+	delivered := nextFrame(t, alicePhone)
+	if delivered.Type != TypeDelivered {
+		t.Fatalf("expected a delivered receipt but got %+v", delivered)
+	}
+
+	if _, ok := <-bobTablet.send; ok {
+		t.Fatalf("expected the disconnected device's send buffer to be closed")
+	}
+}
+
+func TestHandleRead(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		reader       string
+		peer         string
+		expectedType string
+	}{
+		"recipient": {
+			reader:       "bob",
+			peer:         "alice",
+			expectedType: TypeRead,
+		},
+		"sender": {
+			reader:       "alice",
+			peer:         "bob",
+			expectedType: TypeError,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			hub := newTestHub(t, mockstore.New())
+			go hub.Run()
+
+			reader := newTestClient(t, hub, test.reader)
+			peer := newTestClient(t, hub, test.peer)
+
+			data, _ := json.Marshal(Frame{Version: ProtocolVersion, Type: TypeRead, Id: "c-3", MessageId: "m-1", To: test.peer})
+			reader.handle(data)
+
+			// The mock message of the alice_bob conversation is from alice to bob
+			var frame Frame
+			if test.expectedType == TypeError {
+				frame = nextFrame(t, reader)
+			} else {
+				frame = nextFrame(t, peer)
+			}
+
+			if frame.Type != test.expectedType {
+				t.Fatalf("expected a %s frame but got %+v", test.expectedType, frame)
+			}
+		})
+	}
+}
+
+func TestHandleGroupMessage(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		sender       string
+		expectedType string
+	}{
+		// The storefront mock's group has alice, bob and the mock user as members
+		"member": {
+			sender:       "alice",
+			expectedType: TypeAck,
+		},
+		"not a member": {
+			sender:       "carol",
+			expectedType: TypeError,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			hub := newTestHub(t, mockstore.New())
+			go hub.Run()
+
+			sender := newTestClient(t, hub, test.sender)
+			bob := newTestClient(t, hub, "bob")
+
+			sender.handle([]byte(`{"v": 1, "type": "message", "id": "c-4", "group": "5f0d7c1e-2b8a-4f3e-9c6d-7a1b2c3d4e5f", "body": "hello all"}`))
+
+			reply := nextFrame(t, sender)
+			if reply.Type != test.expectedType {
+				t.Fatalf("expected a %s frame but got %+v", test.expectedType, reply)
+			}
+
+			if test.expectedType == TypeError {
+				if reply.Error.Code != ErrForbidden {
+					t.Fatalf("expected error code %s but got %s", ErrForbidden, reply.Error.Code)
+				}
+				return
+			}
+
+			message := nextFrame(t, bob)
+			if message.Type != TypeMessage || message.Group != "5f0d7c1e-2b8a-4f3e-9c6d-7a1b2c3d4e5f" || message.From != test.sender {
+				t.Fatalf("expected bob to receive the group message but got %+v", message)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package ws
+
+import (
+	"github.com/rs/zerolog/log"
+)
+
+// Tell the hub that an access token was revoked, closing the connections
+// opened with it
+func (h *Hub) RevokeToken(tokenID string) {
+	h.revoke <- tokenID
+}
+
+// Close the clients connected with a revoked access token
+func (h *Hub) revokeToken(tokenID string) {
+	for client := range h.clients {
+		if client.tokenId == tokenID {
+			log.Info().Msgf("[/ws] Access token of %s was revoked, closing connection", client.userId)
+			client.revoked = true
+			h.remove(client)
+		}
+	}
+}
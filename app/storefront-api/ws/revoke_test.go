@@ -0,0 +1,37 @@
+package ws
+
+import (
+	"testing"
+
+	mockstore "github.com/anthonydip/flutter-messenger-go/internal/storefront/mock"
+)
+
+func TestRevokeToken(t *testing.T) {
+	t.Parallel()
+
+	hub := newTestHub(t, mockstore.New())
+	go hub.Run()
+
+	phone := newTestClient(t, hub, "bob")
+	phone.tokenId = "phone-token"
+	web := newTestClient(t, hub, "bob")
+	web.tokenId = "web-token"
+
+	hub.RevokeToken("phone-token")
+
+	// The hub closed the send buffer of the client connected with the token
+	if o, ok := <-phone.send; ok {
+		t.Fatalf("expected the revoked client to be closed but got %s", o.data)
+	}
+	if !phone.revoked {
+		t.Fatal("expected the client to be closed as revoked")
+	}
+
+	// The user's other clients stay connected
+	hub.deliver <- &delivery{userId: "bob", frame: Frame{Version: ProtocolVersion, Type: TypeMessage, Id: "m-1", From: "alice", To: "bob", Body: "hello"}}
+
+	frame := nextFrame(t, web)
+	if frame.Type != TypeMessage || frame.Id != "m-1" {
+		t.Fatalf("expected the other client to receive the message but got %+v", frame)
+	}
+}
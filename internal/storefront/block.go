@@ -0,0 +1,109 @@
+package storefront
+
+import (
+	"context"
+
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+
+	"cloud.google.com/go/firestore"
+	"github.com/mitchellh/mapstructure"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Function to remove a friendship on both sides
+func (bkr Broker) DeleteFriend(ctx context.Context, userID string, friendID string) error {
+	users := bkr.Firestore.Collection("users")
+	ref := users.Doc(userID).Collection("friends").Doc(friendID)
+
+	return bkr.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		_, err := tx.Get(ref)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				return ErrFriendNotFound
+			}
+			return err
+		}
+
+		err = tx.Delete(ref)
+		if err != nil {
+			return err
+		}
+
+		return tx.Delete(users.Doc(friendID).Collection("friends").Doc(userID))
+	})
+}
+
+// Function to block a user, which also removes any friendship or pending
+// friend request between the two users
+func (bkr Broker) BlockUser(ctx context.Context, userID string, blocked dtos.BlockedUser) error {
+	users := bkr.Firestore.Collection("users")
+	requests := bkr.Firestore.Collection("friendRequests")
+
+	batch := bkr.Firestore.Batch()
+	batch.Set(users.Doc(userID).Collection("blocked").Doc(blocked.Id), blocked)
+	batch.Delete(users.Doc(userID).Collection("friends").Doc(blocked.Id))
+	batch.Delete(users.Doc(blocked.Id).Collection("friends").Doc(userID))
+	batch.Delete(requests.Doc(FriendRequestID(userID, blocked.Id)))
+	batch.Delete(requests.Doc(FriendRequestID(blocked.Id, userID)))
+
+	_, err := batch.Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Function to unblock a user
+func (bkr Broker) UnblockUser(ctx context.Context, userID string, blockedID string) error {
+	_, err := bkr.Firestore.Collection("users").Doc(userID).Collection("blocked").Doc(blockedID).Delete(ctx, firestore.Exists)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return ErrUserNotBlocked
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Function to get the users a user has blocked
+func (bkr Broker) GetBlockedUsers(ctx context.Context, userID string) ([]dtos.BlockedUser, error) {
+	blocked := make([]dtos.BlockedUser, 0)
+
+	iter := bkr.Firestore.Collection("users").Doc(userID).Collection("blocked").Documents(ctx)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+
+		if err != nil {
+			return make([]dtos.BlockedUser, 0), err
+		}
+
+		if doc.Data() != nil {
+			user := dtos.BlockedUser{}
+
+			mapstructure.Decode(doc.Data(), &user)
+			blocked = append(blocked, user)
+		}
+	}
+
+	return blocked, nil
+}
+
+// Check if a user has blocked another user
+func (bkr Broker) IsBlocked(ctx context.Context, userID string, blockedID string) (bool, error) {
+	_, err := bkr.Firestore.Collection("users").Doc(userID).Collection("blocked").Doc(blockedID).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
@@ -1,9 +1,48 @@
 package storefront
 
+import (
+	"fmt"
+	"time"
+)
+
+// Backends the storefront can store its data in
+const (
+	BackendFirestore = "firestore"
+	BackendMemory    = "memory"
+	BackendSQLite    = "sqlite"
+	BackendPostgres  = "postgres"
+)
+
+// Time whether an access token exists is cached for when no TTL is configured
+const defaultTokenCacheTTL = time.Minute
+
 type Config struct {
+	// Where the storefront stores its data, Firestore when empty
+	Backend string
+
+	// Data source name of the SQLite or PostgreSQL database, e.g. a file path
+	// for SQLite or a postgres:// URL
+	DSN string
+
+	// How long whether an access token exists is cached for, so that a token
+	// revoked on another server is only accepted here until then
+	TokenCacheTTL time.Duration
 }
 
 func validateConfig(cfg Config) error {
-	// This function would be used to validate a hydrated configuration; return an error if its invalid.
+	switch cfg.Backend {
+	case "", BackendFirestore, BackendMemory:
+	case BackendSQLite, BackendPostgres:
+		if cfg.DSN == "" {
+			return fmt.Errorf("the %s backend requires a DSN", cfg.Backend)
+		}
+	default:
+		return fmt.Errorf("unknown backend %q", cfg.Backend)
+	}
+
+	if cfg.TokenCacheTTL < 0 {
+		return fmt.Errorf("negative token cache TTL %s", cfg.TokenCacheTTL)
+	}
+
 	return nil
 }
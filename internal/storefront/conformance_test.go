@@ -0,0 +1,83 @@
+package storefront_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront/storefronttest"
+
+	"cloud.google.com/go/firestore"
+)
+
+func TestMemoryConformance(t *testing.T) {
+	t.Parallel()
+
+	storefronttest.Run(t, storefront.NewMemory())
+}
+
+// The storefront the service uses, with its access token cache
+func TestCachedConformance(t *testing.T) {
+	t.Parallel()
+
+	store, err := storefront.New(storefront.Config{Backend: storefront.BackendMemory})
+	if err != nil {
+		t.Fatalf("couldn't create storefront: %s", err.Error())
+	}
+
+	storefronttest.Run(t, store)
+}
+
+func TestSQLiteConformance(t *testing.T) {
+	t.Parallel()
+
+	store, err := storefront.NewSQL(storefront.BackendSQLite, ":memory:")
+	if err != nil {
+		t.Fatalf("couldn't open database: %s", err.Error())
+	}
+	defer store.Close()
+
+	storefronttest.Run(t, store)
+}
+
+// Runs against the PostgreSQL database of STOREFRONT_TEST_POSTGRES_DSN
+func TestPostgresConformance(t *testing.T) {
+	t.Parallel()
+
+	dsn := os.Getenv("STOREFRONT_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("STOREFRONT_TEST_POSTGRES_DSN is not set")
+	}
+
+	store, err := storefront.NewSQL(storefront.BackendPostgres, dsn)
+	if err != nil {
+		t.Fatalf("couldn't open database: %s", err.Error())
+	}
+	defer store.Close()
+
+	storefronttest.Run(t, store)
+}
+
+// Runs against the Firestore emulator at FIRESTORE_EMULATOR_HOST. Signing up
+// with a password reads SALT_ROUNDS from the .env file, like the service does.
+func TestFirestoreConformance(t *testing.T) {
+	t.Parallel()
+
+	if os.Getenv("FIRESTORE_EMULATOR_HOST") == "" {
+		t.Skip("FIRESTORE_EMULATOR_HOST is not set")
+	}
+
+	projectID := os.Getenv("FIREBASE_PROJECT_ID")
+	if projectID == "" {
+		projectID = "demo-storefront"
+	}
+
+	client, err := firestore.NewClient(context.Background(), projectID)
+	if err != nil {
+		t.Fatalf("couldn't connect to the emulator: %s", err.Error())
+	}
+	defer client.Close()
+
+	storefronttest.Run(t, storefront.Broker{Firestore: client})
+}
@@ -0,0 +1,54 @@
+package storefront
+
+import (
+	"errors"
+)
+
+// Kinds of failure of the storefront, every error returned by a Storefront
+// for a request it refused is one of these, see errors.Is
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrInvalid      = errors.New("invalid")
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+// Errors returned by every Storefront implementation, so that callers can tell
+// them apart with errors.Is instead of matching their messages
+var (
+	ErrUserNotFound          = &Error{kind: ErrNotFound, msg: "user not found"}
+	ErrFriendNotFound        = &Error{kind: ErrNotFound, msg: "friend not found"}
+	ErrFriendRequestNotFound = &Error{kind: ErrNotFound, msg: "friend request not found"}
+	ErrUserNotBlocked        = &Error{kind: ErrNotFound, msg: "user not blocked"}
+	ErrTokenNotFound         = &Error{kind: ErrNotFound, msg: "token not found"}
+	ErrMessageNotFound       = &Error{kind: ErrNotFound, msg: "message not found"}
+	ErrGroupNotFound         = &Error{kind: ErrNotFound, msg: "group not found"}
+
+	ErrEmailTaken            = &Error{kind: ErrConflict, msg: "email already in use"}
+	ErrFriendAlreadyAdded    = &Error{kind: ErrConflict, msg: "friend already added"}
+	ErrFriendRequestSent     = &Error{kind: ErrConflict, msg: "friend request already sent"}
+	ErrFriendRequestReceived = &Error{kind: ErrConflict, msg: "friend request already received"}
+
+	ErrInvalidStatus    = &Error{kind: ErrInvalid, msg: "invalid status"}
+	ErrInvalidDirection = &Error{kind: ErrInvalid, msg: "invalid direction"}
+
+	ErrInvalidPassword     = &Error{kind: ErrUnauthorized, msg: "invalid password"}
+	ErrRefreshTokenExpired = &Error{kind: ErrUnauthorized, msg: "refresh token expired"}
+	ErrRefreshTokenRevoked = &Error{kind: ErrUnauthorized, msg: "refresh token revoked"}
+	ErrRefreshTokenReused  = &Error{kind: ErrUnauthorized, msg: "refresh token reused"}
+)
+
+// Error is an error of the storefront, matching both itself and its kind
+type Error struct {
+	kind error
+	msg  string
+}
+
+func (e *Error) Error() string {
+	return e.msg
+}
+
+// Is reports whether the error is of the target kind
+func (e *Error) Is(target error) bool {
+	return target == e.kind
+}
@@ -0,0 +1,171 @@
+package storefront
+
+import (
+	"context"
+
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+
+	"cloud.google.com/go/firestore"
+	"github.com/mitchellh/mapstructure"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Get the id of the friend request sent by a user to another, a user can only
+// have one pending request to each user
+func FriendRequestID(from string, to string) string {
+	return from + "_" + to
+}
+
+// Function to send a friend request from one user to another
+func (bkr Broker) PostFriendRequest(ctx context.Context, requestInfo dtos.FriendRequest) (dtos.FriendRequest, error) {
+	request := dtos.FriendRequest{
+		Id:        FriendRequestID(requestInfo.From, requestInfo.To),
+		From:      requestInfo.From,
+		To:        requestInfo.To,
+		Timestamp: requestInfo.Timestamp,
+	}
+
+	requests := bkr.Firestore.Collection("friendRequests")
+	friend := bkr.Firestore.Collection("users").Doc(request.From).Collection("friends").Doc(request.To)
+
+	err := bkr.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		refs := []*firestore.DocumentRef{friend, requests.Doc(request.Id), requests.Doc(FriendRequestID(request.To, request.From))}
+
+		dsnaps, err := tx.GetAll(refs)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case dsnaps[0].Exists():
+			return ErrFriendAlreadyAdded
+		case dsnaps[1].Exists():
+			return ErrFriendRequestSent
+		case dsnaps[2].Exists():
+			return ErrFriendRequestReceived
+		}
+
+		return tx.Create(requests.Doc(request.Id), request)
+	})
+	if err != nil {
+		return dtos.FriendRequest{}, err
+	}
+
+	return request, nil
+}
+
+// Function to get a friend request
+func (bkr Broker) GetFriendRequest(ctx context.Context, id string) (dtos.FriendRequest, error) {
+	request := dtos.FriendRequest{}
+
+	dsnap, err := bkr.Firestore.Collection("friendRequests").Doc(id).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return dtos.FriendRequest{}, ErrFriendRequestNotFound
+		}
+		return dtos.FriendRequest{}, err
+	}
+
+	mapstructure.Decode(dsnap.Data(), &request)
+
+	return request, nil
+}
+
+// Function to get the pending friend requests received or sent by a user,
+// oldest first
+func (bkr Broker) GetFriendRequests(ctx context.Context, userID string, direction string) ([]dtos.FriendRequest, error) {
+	requests := make([]dtos.FriendRequest, 0)
+
+	field := "to"
+	switch direction {
+	case dtos.FriendRequestIncoming:
+	case dtos.FriendRequestOutgoing:
+		field = "from"
+	default:
+		return requests, ErrInvalidDirection
+	}
+
+	iter := bkr.Firestore.Collection("friendRequests").Where(field, "==", userID).OrderBy("timestamp", firestore.Asc).Documents(ctx)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+
+		if err != nil {
+			return make([]dtos.FriendRequest, 0), err
+		}
+
+		if doc.Data() != nil {
+			request := dtos.FriendRequest{}
+
+			mapstructure.Decode(doc.Data(), &request)
+			requests = append(requests, request)
+		}
+	}
+
+	return requests, nil
+}
+
+// Function to accept a friend request, adding each user to the other's friends
+// and removing the request in a single transaction
+func (bkr Broker) AcceptFriendRequest(ctx context.Context, id string) error {
+	ref := bkr.Firestore.Collection("friendRequests").Doc(id)
+	users := bkr.Firestore.Collection("users")
+
+	return bkr.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		dsnap, err := tx.Get(ref)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				return ErrFriendRequestNotFound
+			}
+			return err
+		}
+
+		request := dtos.FriendRequest{}
+		mapstructure.Decode(dsnap.Data(), &request)
+
+		dsnaps, err := tx.GetAll([]*firestore.DocumentRef{users.Doc(request.From), users.Doc(request.To)})
+		if err != nil {
+			return err
+		}
+
+		friends := make([]dtos.Friend, 0, len(dsnaps))
+		for _, dsnap := range dsnaps {
+			if !dsnap.Exists() {
+				return ErrUserNotFound
+			}
+
+			user := dtos.User{}
+			mapstructure.Decode(dsnap.Data(), &user)
+			friends = append(friends, dtos.Friend{Id: user.Id, Email: user.Email})
+		}
+
+		err = tx.Set(users.Doc(request.From).Collection("friends").Doc(request.To), friends[1])
+		if err != nil {
+			return err
+		}
+
+		err = tx.Set(users.Doc(request.To).Collection("friends").Doc(request.From), friends[0])
+		if err != nil {
+			return err
+		}
+
+		return tx.Delete(ref)
+	})
+}
+
+// Function to delete a friend request, when it is declined or cancelled
+func (bkr Broker) DeleteFriendRequest(ctx context.Context, id string) error {
+	_, err := bkr.Firestore.Collection("friendRequests").Doc(id).Delete(ctx, firestore.Exists)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return ErrFriendRequestNotFound
+		}
+		return err
+	}
+
+	return nil
+}
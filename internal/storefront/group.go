@@ -0,0 +1,111 @@
+package storefront
+
+import (
+	"context"
+
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+
+	"cloud.google.com/go/firestore"
+	"github.com/google/uuid"
+	"github.com/mitchellh/mapstructure"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Function to create a new group
+func (bkr Broker) CreateGroup(ctx context.Context, groupInfo dtos.Group) (dtos.Group, error) {
+	group := dtos.Group{
+		Id:      uuid.New().String(),
+		Name:    groupInfo.Name,
+		Members: groupInfo.Members,
+	}
+
+	_, err := bkr.Firestore.Collection("groups").Doc(group.Id).Set(ctx, group)
+	if err != nil {
+		return dtos.Group{}, err
+	}
+
+	return group, nil
+}
+
+// Function to get a group
+func (bkr Broker) GetGroup(ctx context.Context, id string) (dtos.Group, error) {
+	group := dtos.Group{}
+
+	dsnap, err := bkr.Firestore.Collection("groups").Doc(id).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return dtos.Group{}, ErrGroupNotFound
+		}
+		return dtos.Group{}, err
+	}
+
+	mapstructure.Decode(dsnap.Data(), &group)
+
+	return group, nil
+}
+
+// Function to get all the groups a user is a member of
+func (bkr Broker) GetGroups(ctx context.Context, userID string) ([]dtos.Group, error) {
+	groups := make([]dtos.Group, 0)
+
+	iter := bkr.Firestore.Collection("groups").WherePath(firestore.FieldPath{"members", userID}, "in", []string{dtos.GroupAdmin, dtos.GroupMember}).Documents(ctx)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+
+		if err != nil {
+			return make([]dtos.Group, 0), err
+		}
+
+		if doc.Data() != nil {
+			group := dtos.Group{}
+
+			mapstructure.Decode(doc.Data(), &group)
+			groups = append(groups, group)
+		}
+	}
+
+	return groups, nil
+}
+
+// Function to rename a group
+func (bkr Broker) RenameGroup(ctx context.Context, id string, name string) error {
+	return bkr.updateGroup(ctx, id, firestore.Update{Path: "name", Value: name})
+}
+
+// Function to add a member to a group, or change the role of an existing member
+func (bkr Broker) SetGroupMember(ctx context.Context, id string, userID string, role string) error {
+	return bkr.updateGroup(ctx, id, firestore.Update{FieldPath: firestore.FieldPath{"members", userID}, Value: role})
+}
+
+// Function to remove a member from a group
+func (bkr Broker) RemoveGroupMember(ctx context.Context, id string, userID string) error {
+	return bkr.updateGroup(ctx, id, firestore.Update{FieldPath: firestore.FieldPath{"members", userID}, Value: firestore.Delete})
+}
+
+// Function to delete a group
+func (bkr Broker) DeleteGroup(ctx context.Context, id string) error {
+	_, err := bkr.Firestore.Collection("groups").Doc(id).Delete(ctx)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Apply an update to an existing group
+func (bkr Broker) updateGroup(ctx context.Context, id string, update firestore.Update) error {
+	_, err := bkr.Firestore.Collection("groups").Doc(id).Update(ctx, []firestore.Update{update})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return ErrGroupNotFound
+		}
+		return err
+	}
+
+	return nil
+}
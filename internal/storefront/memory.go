@@ -0,0 +1,744 @@
+package storefront
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Memory is a Storefront keeping all of its data in memory, for local
+// development and tests. It behaves like the Firestore storefront, errors
+// included, but loses everything when the process exits.
+type Memory struct {
+	mu sync.RWMutex
+
+	users          map[string]dtos.User
+	friends        map[string]map[string]dtos.Friend
+	tokens         map[string]TokenInfo
+	refreshTokens  map[string]RefreshToken
+	conversations  map[string]map[string]dtos.Message
+	queues         map[string]map[string]dtos.Message
+	presence       map[string]dtos.Presence
+	groups         map[string]dtos.Group
+	friendRequests map[string]dtos.FriendRequest
+	blocked        map[string]map[string]dtos.BlockedUser
+
+	cost int // bcrypt cost of user passwords
+}
+
+// Initialize a new, empty in-memory Storefront
+func NewMemory() *Memory {
+	return &Memory{
+		users:          make(map[string]dtos.User),
+		friends:        make(map[string]map[string]dtos.Friend),
+		tokens:         make(map[string]TokenInfo),
+		refreshTokens:  make(map[string]RefreshToken),
+		conversations:  make(map[string]map[string]dtos.Message),
+		queues:         make(map[string]map[string]dtos.Message),
+		presence:       make(map[string]dtos.Presence),
+		groups:         make(map[string]dtos.Group),
+		friendRequests: make(map[string]dtos.FriendRequest),
+		blocked:        make(map[string]map[string]dtos.BlockedUser),
+
+		cost: bcrypt.DefaultCost,
+	}
+}
+
+func (m *Memory) GetUser(ctx context.Context, id string) (dtos.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	user, ok := m.users[id]
+	if !ok {
+		return dtos.User{}, ErrUserNotFound
+	}
+
+	user.Password = ""
+
+	return user, nil
+}
+
+func (m *Memory) GetUserByEmail(ctx context.Context, email string) (dtos.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	user, ok := m.userByEmail(email)
+	if !ok {
+		return dtos.User{}, ErrUserNotFound
+	}
+
+	user.Password = ""
+
+	return user, nil
+}
+
+func (m *Memory) GetAllFriends(ctx context.Context, id string) ([]dtos.Friend, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	friends := make([]dtos.Friend, 0, len(m.friends[id]))
+	for _, friend := range m.friends[id] {
+		friends = append(friends, friend)
+	}
+
+	sort.Slice(friends, func(i, j int) bool { return friends[i].Id < friends[j].Id })
+
+	return friends, nil
+}
+
+// Sign a user in, checking password
+func (m *Memory) SignIn(ctx context.Context, userInfo dtos.User) error {
+	m.mu.RLock()
+	user, ok := m.userByEmail(userInfo.Email)
+	m.mu.RUnlock()
+
+	if !ok {
+		return ErrUserNotFound
+	}
+
+	// Validate the request password is the same as the hash password
+	err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(userInfo.Password))
+	if err != nil {
+		return ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (m *Memory) PostUser(ctx context.Context, userInfo dtos.User) (dtos.User, error) {
+	user := dtos.User{
+		Id:       uuid.New().String(),
+		Email:    userInfo.Email,
+		Provider: userInfo.Provider,
+	}
+
+	// Hash the password outside of the lock, it is slow on purpose
+	if userInfo.Provider == "Flutter" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(userInfo.Password), m.cost)
+		if err != nil {
+			return dtos.User{}, err
+		}
+
+		user.Password = string(hash)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Check if the email already exists
+	if _, ok := m.userByEmail(userInfo.Email); ok {
+		return dtos.User{}, ErrEmailTaken
+	}
+
+	m.users[user.Id] = user
+
+	return user, nil
+}
+
+// Check if a user has added another user as a friend
+func (m *Memory) IsFriend(ctx context.Context, userID string, friendID string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, ok := m.friends[userID][friendID]
+
+	return ok, nil
+}
+
+// Function to check if an access token exists, by its ID
+func (m *Memory) AccessTokenExists(ctx context.Context, tokenID string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.tokens[tokenID]; !ok {
+		return ErrTokenNotFound
+	}
+
+	return nil
+}
+
+// Function to get the access token with an ID
+func (m *Memory) GetAccessToken(ctx context.Context, tokenID string) (TokenInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	info, ok := m.tokens[tokenID]
+	if !ok {
+		return TokenInfo{}, ErrTokenNotFound
+	}
+
+	return info, nil
+}
+
+// Function to delete the access token with an ID, revoking it
+func (m *Memory) DeleteAccessToken(ctx context.Context, tokenID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.tokens, tokenID)
+
+	return nil
+}
+
+// Function to add the access token with an ID, along with the refresh token
+// family issued with it
+func (m *Memory) AddAccessToken(ctx context.Context, tokenID string, user dtos.User, familyID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tokens[tokenID] = TokenInfo{
+		"user",
+		user.Id,
+		user.Email,
+		user.Provider,
+		familyID,
+	}
+
+	return nil
+}
+
+// Function to add a refresh token
+func (m *Memory) AddRefreshToken(ctx context.Context, token string, info RefreshToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.refreshTokens[refreshTokenKey(token)] = info
+
+	return nil
+}
+
+// Function to exchange a refresh token, marking it used so that it can only
+// be exchanged once. Presenting a used token again revokes its whole family
+// and deletes the access tokens issued with it, whose IDs are returned.
+func (m *Memory) UseRefreshToken(ctx context.Context, token string) (RefreshToken, []string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := refreshTokenKey(token)
+	info, ok := m.refreshTokens[key]
+	if !ok {
+		return RefreshToken{}, nil, ErrTokenNotFound
+	}
+
+	err := refreshTokenError(info, time.Now())
+	if errors.Is(err, ErrRefreshTokenReused) {
+		m.revokeRefreshTokens(info.FamilyId)
+
+		var tokenIDs []string
+		for tokenID, token := range m.tokens {
+			if token.FamilyId == info.FamilyId {
+				delete(m.tokens, tokenID)
+				tokenIDs = append(tokenIDs, tokenID)
+			}
+		}
+
+		return RefreshToken{}, tokenIDs, err
+	}
+	if err != nil {
+		return RefreshToken{}, nil, err
+	}
+
+	info.Used = true
+	m.refreshTokens[key] = info
+
+	return info, nil, nil
+}
+
+// Function to revoke every refresh token of a family
+func (m *Memory) RevokeRefreshTokens(ctx context.Context, familyID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.revokeRefreshTokens(familyID)
+
+	return nil
+}
+
+// Revoke every refresh token of a family, the lock must be held
+func (m *Memory) revokeRefreshTokens(familyID string) {
+	for key, info := range m.refreshTokens {
+		if info.FamilyId == familyID {
+			info.Revoked = true
+			m.refreshTokens[key] = info
+		}
+	}
+}
+
+// Function to store a message in its conversation
+func (m *Memory) SaveMessage(ctx context.Context, message dtos.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.conversations[message.ConversationId]; !ok {
+		m.conversations[message.ConversationId] = make(map[string]dtos.Message)
+	}
+	m.conversations[message.ConversationId][message.Id] = message
+
+	return nil
+}
+
+// Function to list the messages of a conversation, newest first, starting after the message id before
+func (m *Memory) GetMessages(ctx context.Context, conversationID string, before string, limit int) ([]dtos.Message, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	conversation := m.conversations[conversationID]
+
+	messages := make([]dtos.Message, 0, len(conversation))
+	for _, message := range conversation {
+		messages = append(messages, message)
+	}
+
+	// Ties are broken by id, in the same direction as Firestore does
+	sort.Slice(messages, func(i, j int) bool {
+		if messages[i].ServerTimestamp != messages[j].ServerTimestamp {
+			return messages[i].ServerTimestamp > messages[j].ServerTimestamp
+		}
+		return messages[i].Id > messages[j].Id
+	})
+
+	if before != "" {
+		if _, ok := conversation[before]; !ok {
+			return make([]dtos.Message, 0), ErrMessageNotFound
+		}
+
+		for i, message := range messages {
+			if message.Id == before {
+				messages = messages[i+1:]
+				break
+			}
+		}
+	}
+
+	if len(messages) > limit {
+		messages = messages[:limit]
+	}
+
+	return messages, nil
+}
+
+// Function to get a message of a conversation
+func (m *Memory) GetMessage(ctx context.Context, conversationID string, messageID string) (dtos.Message, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	message, ok := m.conversations[conversationID][messageID]
+	if !ok {
+		return dtos.Message{}, ErrMessageNotFound
+	}
+
+	return message, nil
+}
+
+// Function to move a message's status forward, reporting whether it changed
+func (m *Memory) UpdateMessageStatus(ctx context.Context, conversationID string, messageID string, messageStatus string) (bool, error) {
+	if _, ok := messageStatusOrder[messageStatus]; !ok {
+		return false, ErrInvalidStatus
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	message, ok := m.conversations[conversationID][messageID]
+	if !ok {
+		return false, ErrMessageNotFound
+	}
+
+	if messageStatusOrder[messageStatus] <= messageStatusOrder[message.Status] {
+		return false, nil
+	}
+
+	message.Status = messageStatus
+	m.conversations[conversationID][messageID] = message
+
+	return true, nil
+}
+
+// Function to queue a message until the recipient acknowledges it
+func (m *Memory) QueueMessage(ctx context.Context, userID string, message dtos.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.queues[userID]; !ok {
+		m.queues[userID] = make(map[string]dtos.Message)
+	}
+	m.queues[userID][message.Id] = message
+
+	return nil
+}
+
+// Function to get the messages queued for a user, oldest first
+func (m *Memory) GetQueuedMessages(ctx context.Context, userID string) ([]dtos.Message, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	messages := make([]dtos.Message, 0, len(m.queues[userID]))
+	for _, message := range m.queues[userID] {
+		messages = append(messages, message)
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		if messages[i].ServerTimestamp != messages[j].ServerTimestamp {
+			return messages[i].ServerTimestamp < messages[j].ServerTimestamp
+		}
+		return messages[i].Id < messages[j].Id
+	})
+
+	return messages, nil
+}
+
+// Function to remove an acknowledged message from a user's queue
+func (m *Memory) DeleteQueuedMessage(ctx context.Context, userID string, messageID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.queues[userID], messageID)
+
+	return nil
+}
+
+// Function to store the presence of a user
+func (m *Memory) SavePresence(ctx context.Context, presence dtos.Presence) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.presence[presence.Id] = presence
+
+	return nil
+}
+
+// Function to get the last stored presence of a user, users never seen are offline
+func (m *Memory) GetPresence(ctx context.Context, userID string) (dtos.Presence, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	presence, ok := m.presence[userID]
+	if !ok {
+		return dtos.Presence{Id: userID, Status: dtos.PresenceOffline}, nil
+	}
+
+	return presence, nil
+}
+
+// Function to create a new group
+func (m *Memory) CreateGroup(ctx context.Context, groupInfo dtos.Group) (dtos.Group, error) {
+	group := dtos.Group{
+		Id:      uuid.New().String(),
+		Name:    groupInfo.Name,
+		Members: groupInfo.Members,
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.groups[group.Id] = copyGroup(group)
+
+	return group, nil
+}
+
+// Function to get a group
+func (m *Memory) GetGroup(ctx context.Context, id string) (dtos.Group, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	group, ok := m.groups[id]
+	if !ok {
+		return dtos.Group{}, ErrGroupNotFound
+	}
+
+	return copyGroup(group), nil
+}
+
+// Function to get all the groups a user is a member of
+func (m *Memory) GetGroups(ctx context.Context, userID string) ([]dtos.Group, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	groups := make([]dtos.Group, 0)
+	for _, group := range m.groups {
+		if _, ok := group.Members[userID]; ok {
+			groups = append(groups, copyGroup(group))
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Id < groups[j].Id })
+
+	return groups, nil
+}
+
+// Function to rename a group
+func (m *Memory) RenameGroup(ctx context.Context, id string, name string) error {
+	return m.updateGroup(id, func(group *dtos.Group) {
+		group.Name = name
+	})
+}
+
+// Function to add a member to a group, or change the role of an existing member
+func (m *Memory) SetGroupMember(ctx context.Context, id string, userID string, role string) error {
+	return m.updateGroup(id, func(group *dtos.Group) {
+		if group.Members == nil {
+			group.Members = make(map[string]string)
+		}
+		group.Members[userID] = role
+	})
+}
+
+// Function to remove a member from a group
+func (m *Memory) RemoveGroupMember(ctx context.Context, id string, userID string) error {
+	return m.updateGroup(id, func(group *dtos.Group) {
+		delete(group.Members, userID)
+	})
+}
+
+// Function to delete a group
+func (m *Memory) DeleteGroup(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.groups, id)
+
+	return nil
+}
+
+// Apply an update to an existing group
+func (m *Memory) updateGroup(id string, update func(group *dtos.Group)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	group, ok := m.groups[id]
+	if !ok {
+		return ErrGroupNotFound
+	}
+
+	// Groups are stored as copies, the stored members can be updated in place
+	update(&group)
+	m.groups[id] = group
+
+	return nil
+}
+
+// Function to send a friend request from one user to another
+func (m *Memory) PostFriendRequest(ctx context.Context, requestInfo dtos.FriendRequest) (dtos.FriendRequest, error) {
+	request := dtos.FriendRequest{
+		Id:        FriendRequestID(requestInfo.From, requestInfo.To),
+		From:      requestInfo.From,
+		To:        requestInfo.To,
+		Timestamp: requestInfo.Timestamp,
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.friends[request.From][request.To]; ok {
+		return dtos.FriendRequest{}, ErrFriendAlreadyAdded
+	}
+
+	if _, ok := m.friendRequests[request.Id]; ok {
+		return dtos.FriendRequest{}, ErrFriendRequestSent
+	}
+
+	if _, ok := m.friendRequests[FriendRequestID(request.To, request.From)]; ok {
+		return dtos.FriendRequest{}, ErrFriendRequestReceived
+	}
+
+	m.friendRequests[request.Id] = request
+
+	return request, nil
+}
+
+// Function to get a friend request
+func (m *Memory) GetFriendRequest(ctx context.Context, id string) (dtos.FriendRequest, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	request, ok := m.friendRequests[id]
+	if !ok {
+		return dtos.FriendRequest{}, ErrFriendRequestNotFound
+	}
+
+	return request, nil
+}
+
+// Function to get the pending friend requests received or sent by a user,
+// oldest first
+func (m *Memory) GetFriendRequests(ctx context.Context, userID string, direction string) ([]dtos.FriendRequest, error) {
+	requests := make([]dtos.FriendRequest, 0)
+
+	if direction != dtos.FriendRequestIncoming && direction != dtos.FriendRequestOutgoing {
+		return requests, ErrInvalidDirection
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, request := range m.friendRequests {
+		if (direction == dtos.FriendRequestIncoming && request.To == userID) || (direction == dtos.FriendRequestOutgoing && request.From == userID) {
+			requests = append(requests, request)
+		}
+	}
+
+	sort.Slice(requests, func(i, j int) bool {
+		if requests[i].Timestamp != requests[j].Timestamp {
+			return requests[i].Timestamp < requests[j].Timestamp
+		}
+		return requests[i].Id < requests[j].Id
+	})
+
+	return requests, nil
+}
+
+// Function to accept a friend request, adding each user to the other's friends
+// and removing the request at once
+func (m *Memory) AcceptFriendRequest(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	request, ok := m.friendRequests[id]
+	if !ok {
+		return ErrFriendRequestNotFound
+	}
+
+	from, ok := m.users[request.From]
+	if !ok {
+		return ErrUserNotFound
+	}
+
+	to, ok := m.users[request.To]
+	if !ok {
+		return ErrUserNotFound
+	}
+
+	m.addFriend(from.Id, dtos.Friend{Id: to.Id, Email: to.Email})
+	m.addFriend(to.Id, dtos.Friend{Id: from.Id, Email: from.Email})
+	delete(m.friendRequests, id)
+
+	return nil
+}
+
+// Function to delete a friend request, when it is declined or cancelled
+func (m *Memory) DeleteFriendRequest(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.friendRequests[id]; !ok {
+		return ErrFriendRequestNotFound
+	}
+
+	delete(m.friendRequests, id)
+
+	return nil
+}
+
+// Function to remove a friendship on both sides
+func (m *Memory) DeleteFriend(ctx context.Context, userID string, friendID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.friends[userID][friendID]; !ok {
+		return ErrFriendNotFound
+	}
+
+	delete(m.friends[userID], friendID)
+	delete(m.friends[friendID], userID)
+
+	return nil
+}
+
+// Function to block a user, which also removes any friendship or pending
+// friend request between the two users
+func (m *Memory) BlockUser(ctx context.Context, userID string, blocked dtos.BlockedUser) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.blocked[userID]; !ok {
+		m.blocked[userID] = make(map[string]dtos.BlockedUser)
+	}
+	m.blocked[userID][blocked.Id] = blocked
+
+	delete(m.friends[userID], blocked.Id)
+	delete(m.friends[blocked.Id], userID)
+	delete(m.friendRequests, FriendRequestID(userID, blocked.Id))
+	delete(m.friendRequests, FriendRequestID(blocked.Id, userID))
+
+	return nil
+}
+
+// Function to unblock a user
+func (m *Memory) UnblockUser(ctx context.Context, userID string, blockedID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.blocked[userID][blockedID]; !ok {
+		return ErrUserNotBlocked
+	}
+
+	delete(m.blocked[userID], blockedID)
+
+	return nil
+}
+
+// Function to get the users a user has blocked
+func (m *Memory) GetBlockedUsers(ctx context.Context, userID string) ([]dtos.BlockedUser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	blocked := make([]dtos.BlockedUser, 0, len(m.blocked[userID]))
+	for _, user := range m.blocked[userID] {
+		blocked = append(blocked, user)
+	}
+
+	sort.Slice(blocked, func(i, j int) bool { return blocked[i].Id < blocked[j].Id })
+
+	return blocked, nil
+}
+
+// Check if a user has blocked another user
+func (m *Memory) IsBlocked(ctx context.Context, userID string, blockedID string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, ok := m.blocked[userID][blockedID]
+
+	return ok, nil
+}
+
+// Find a user by email, the caller must hold the lock
+func (m *Memory) userByEmail(email string) (dtos.User, bool) {
+	for _, user := range m.users {
+		if user.Email == email {
+			return user, true
+		}
+	}
+
+	return dtos.User{}, false
+}
+
+// Add a friend to a user's friends, the caller must hold the write lock
+func (m *Memory) addFriend(userID string, friend dtos.Friend) {
+	if _, ok := m.friends[userID]; !ok {
+		m.friends[userID] = make(map[string]dtos.Friend)
+	}
+	m.friends[userID][friend.Id] = friend
+}
+
+// Copy a group so that its members are not shared with the caller
+func copyGroup(group dtos.Group) dtos.Group {
+	if group.Members == nil {
+		return group
+	}
+
+	members := make(map[string]string, len(group.Members))
+	for id, role := range group.Members {
+		members[id] = role
+	}
+	group.Members = members
+
+	return group
+}
@@ -0,0 +1,149 @@
+package storefront
+
+import (
+	"context"
+
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+
+	"cloud.google.com/go/firestore"
+	"github.com/mitchellh/mapstructure"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Position of each message status, a message's status only ever moves forward
+var messageStatusOrder = map[string]int{
+	dtos.MessageSent:      1,
+	dtos.MessageDelivered: 2,
+	dtos.MessageRead:      3,
+}
+
+// ConversationID returns the id of the private conversation between two users,
+// which is the same regardless of who sent the message
+func ConversationID(userID string, peerID string) string {
+	if userID > peerID {
+		userID, peerID = peerID, userID
+	}
+
+	return userID + "_" + peerID
+}
+
+// Function to store a message in its conversation
+func (bkr Broker) SaveMessage(ctx context.Context, message dtos.Message) error {
+	conversation := bkr.Firestore.Collection("conversations").Doc(message.ConversationId)
+
+	// Group conversations keep their members on the group
+	members := []interface{}{message.From}
+	if message.To != "" {
+		members = append(members, message.To)
+	}
+
+	batch := bkr.Firestore.Batch()
+	batch.Set(conversation, map[string]interface{}{
+		"id":        message.ConversationId,
+		"members":   firestore.ArrayUnion(members...),
+		"updatedAt": message.ServerTimestamp,
+	}, firestore.MergeAll)
+	batch.Set(conversation.Collection("messages").Doc(message.Id), message)
+
+	_, err := batch.Commit(ctx)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Function to list the messages of a conversation, newest first, starting after the message id before
+func (bkr Broker) GetMessages(ctx context.Context, conversationID string, before string, limit int) ([]dtos.Message, error) {
+	messages := make([]dtos.Message, 0)
+
+	collection := bkr.Firestore.Collection("conversations").Doc(conversationID).Collection("messages")
+	query := collection.OrderBy("serverTimestamp", firestore.Desc)
+
+	if before != "" {
+		dsnap, err := collection.Doc(before).Get(ctx)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				return make([]dtos.Message, 0), ErrMessageNotFound
+			}
+			return make([]dtos.Message, 0), err
+		}
+
+		query = query.StartAfter(dsnap)
+	}
+
+	iter := query.Limit(limit).Documents(ctx)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+
+		if err != nil {
+			return make([]dtos.Message, 0), err
+		}
+
+		if doc.Data() != nil {
+			message := dtos.Message{}
+
+			mapstructure.Decode(doc.Data(), &message)
+			messages = append(messages, message)
+		}
+	}
+
+	return messages, nil
+}
+
+// Function to get a single message of a conversation
+func (bkr Broker) GetMessage(ctx context.Context, conversationID string, messageID string) (dtos.Message, error) {
+	message := dtos.Message{}
+
+	dsnap, err := bkr.Firestore.Collection("conversations").Doc(conversationID).Collection("messages").Doc(messageID).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return dtos.Message{}, ErrMessageNotFound
+		}
+		return dtos.Message{}, err
+	}
+
+	mapstructure.Decode(dsnap.Data(), &message)
+
+	return message, nil
+}
+
+// Function to move a message to a later status, returning false if it already had that status or a later one
+func (bkr Broker) UpdateMessageStatus(ctx context.Context, conversationID string, messageID string, messageStatus string) (bool, error) {
+	if _, ok := messageStatusOrder[messageStatus]; !ok {
+		return false, ErrInvalidStatus
+	}
+
+	ref := bkr.Firestore.Collection("conversations").Doc(conversationID).Collection("messages").Doc(messageID)
+	updated := false
+
+	err := bkr.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		dsnap, err := tx.Get(ref)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				return ErrMessageNotFound
+			}
+			return err
+		}
+
+		message := dtos.Message{}
+		mapstructure.Decode(dsnap.Data(), &message)
+
+		updated = messageStatusOrder[messageStatus] > messageStatusOrder[message.Status]
+		if !updated {
+			return nil
+		}
+
+		return tx.Update(ref, []firestore.Update{{Path: "status", Value: messageStatus}})
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return updated, nil
+}
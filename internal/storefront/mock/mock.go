@@ -1,6 +1,12 @@
 package mock
 
 import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
 	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
 )
 
@@ -13,6 +19,23 @@ type mockConfig struct {
 	postUser          error
 	addAccessToken    error
 	deleteAccessToken error
+	accessTokenExists error
+	getAccessToken    error
+	addRefreshToken   error
+	useRefreshToken   error
+	revokeRefresh     error
+	saveMessage       error
+	getMessages       error
+	queueMessage      error
+	getMessage        error
+	notFriends        bool
+	getGroup          error
+	postFriendRequest error
+	getFriendRequest  error
+	deleteFriend      error
+	blockUser         error
+	unblockUser       error
+	blocked           bool
 }
 
 // Mock for mocking Storefront service
@@ -34,7 +57,7 @@ func New(opts ...Result) Mock {
 }
 
 // GetUser mocks Storefront GetUser() call
-func (m Mock) GetUser(string) (dtos.User, error) {
+func (m Mock) GetUser(context.Context, string) (dtos.User, error) {
 	if m.cfg.getUser != nil {
 		return dtos.User{}, m.cfg.getUser
 	}
@@ -54,12 +77,22 @@ func GetUserResult(e error) Result {
 	}
 }
 
-// GetUserByEmail mocks Storefront GetUserByEmail() call
-func (m Mock) GetUserByEmail(string) (dtos.User, error) {
+// GetUserByEmail mocks Storefront GetUserByEmail() call, any email other than
+// the storefront mock's user belongs to another user
+func (m Mock) GetUserByEmail(ctx context.Context, email string) (dtos.User, error) {
 	if m.cfg.getUserByEmail != nil {
 		return dtos.User{}, m.cfg.getUserByEmail
 	}
 
+	if email != "" && email != "mock@storefront-mock.com" {
+		return dtos.User{
+			Id:       "1c5e2f3a-7d4b-4e8a-9f60-3b2a1d0c9e87",
+			Email:    email,
+			Provider: "Flutter",
+			Password: "*****",
+		}, nil
+	}
+
 	return dtos.User{
 		Id:       "8ae84a23-fa49-45eb-8000-bdc9b9fe074a",
 		Email:    "mock@storefront-mock.com",
@@ -76,7 +109,7 @@ func GetUserByEmailResult(e error) Result {
 }
 
 // SignIn mocks Storefront SignIn() call
-func (m Mock) SignIn(dtos.User) error {
+func (m Mock) SignIn(context.Context, dtos.User) error {
 	if m.cfg.signIn != nil {
 		return m.cfg.signIn
 	}
@@ -92,7 +125,7 @@ func SignInResult(e error) Result {
 }
 
 // PostUser mocks Storefront PostUser() call
-func (m Mock) PostUser(dtos.User) (dtos.User, error) {
+func (m Mock) PostUser(context.Context, dtos.User) (dtos.User, error) {
 	if m.cfg.postUser != nil {
 		return dtos.User{}, m.cfg.postUser
 	}
@@ -113,7 +146,7 @@ func PostUserResult(e error) Result {
 }
 
 // AddAccessToken mocks Storefront AddAccessToken() call
-func (m Mock) AddAccessToken(string, dtos.User) error {
+func (m Mock) AddAccessToken(context.Context, string, dtos.User, string) error {
 	if m.cfg.addAccessToken != nil {
 		return m.cfg.addAccessToken
 	}
@@ -129,7 +162,7 @@ func AddAccessTokenResult(e error) Result {
 }
 
 // DeleteAccessToken mocks Storefront DeleteAccessToken() call
-func (m Mock) DeleteAccessToken(string) error {
+func (m Mock) DeleteAccessToken(context.Context, string) error {
 	if m.cfg.deleteAccessToken != nil {
 		return m.cfg.deleteAccessToken
 	}
@@ -144,17 +177,420 @@ func DeleteAccessTokenResult(e error) Result {
 	}
 }
 
+// AddRefreshToken mocks Storefront AddRefreshToken() call
+func (m Mock) AddRefreshToken(context.Context, string, storefront.RefreshToken) error {
+	if m.cfg.addRefreshToken != nil {
+		return m.cfg.addRefreshToken
+	}
+
+	return nil
+}
+
+// AddRefreshTokenResult sets the result of the mock AddRefreshToken()
+func AddRefreshTokenResult(e error) Result {
+	return func(c *mockConfig) {
+		c.addRefreshToken = e
+	}
+}
+
+// UseRefreshToken mocks Storefront UseRefreshToken() call
+func (m Mock) UseRefreshToken(context.Context, string) (storefront.RefreshToken, []string, error) {
+	if errors.Is(m.cfg.useRefreshToken, storefront.ErrRefreshTokenReused) {
+		return storefront.RefreshToken{}, []string{"5b9f3c2e-7d41-4a8e-9c6b-2f0e8a1d4c73"}, m.cfg.useRefreshToken
+	}
+	if m.cfg.useRefreshToken != nil {
+		return storefront.RefreshToken{}, nil, m.cfg.useRefreshToken
+	}
+
+	return storefront.RefreshToken{
+		FamilyId:  "0f4c5e2a-9b7d-4c1e-8a3f-6d2b1e0c9a87",
+		UserId:    "8ae84a23-fa49-45eb-8000-bdc9b9fe074a",
+		Email:     "mock@storefront-mock.com",
+		Provider:  "Flutter",
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+		Used:      true,
+	}, nil, nil
+}
+
+// UseRefreshTokenResult sets the result of the mock UseRefreshToken()
+func UseRefreshTokenResult(e error) Result {
+	return func(c *mockConfig) {
+		c.useRefreshToken = e
+	}
+}
+
+// RevokeRefreshTokens mocks Storefront RevokeRefreshTokens() call
+func (m Mock) RevokeRefreshTokens(context.Context, string) error {
+	if m.cfg.revokeRefresh != nil {
+		return m.cfg.revokeRefresh
+	}
+
+	return nil
+}
+
+// RevokeRefreshTokensResult sets the result of the mock RevokeRefreshTokens()
+func RevokeRefreshTokensResult(e error) Result {
+	return func(c *mockConfig) {
+		c.revokeRefresh = e
+	}
+}
+
+// AccessTokenExists mocks Storefront AccessTokenExists() call
+func (m Mock) AccessTokenExists(context.Context, string) error {
+	if m.cfg.accessTokenExists != nil {
+		return m.cfg.accessTokenExists
+	}
+
+	return nil
+}
+
+// AccessTokenExistsResult sets the result of the mock AccessTokenExists()
+func AccessTokenExistsResult(e error) Result {
+	return func(c *mockConfig) {
+		c.accessTokenExists = e
+	}
+}
+
+// GetAccessToken mocks Storefront GetAccessToken() call
+func (m Mock) GetAccessToken(context.Context, string) (storefront.TokenInfo, error) {
+	if m.cfg.getAccessToken != nil {
+		return storefront.TokenInfo{}, m.cfg.getAccessToken
+	}
+
+	return storefront.TokenInfo{
+		TokenType: "user",
+		Id:        "8ae84a23-fa49-45eb-8000-bdc9b9fe074a",
+		Email:     "mock@storefront-mock.com",
+		Provider:  "Flutter",
+		FamilyId:  "0f4c5e2a-9b7d-4c1e-8a3f-6d2b1e0c9a87",
+	}, nil
+}
+
+// GetAccessTokenResult sets the result of the mock GetAccessToken()
+func GetAccessTokenResult(e error) Result {
+	return func(c *mockConfig) {
+		c.getAccessToken = e
+	}
+}
+
+// IsFriend mocks Storefront IsFriend() call
+func (m Mock) IsFriend(context.Context, string, string) (bool, error) {
+	return !m.cfg.notFriends, nil
+}
+
+// IsFriendFail makes the mock IsFriend() report users are not friends
+func IsFriendFail() Result {
+	return func(c *mockConfig) {
+		c.notFriends = true
+	}
+}
+
+// TODO
+func (m Mock) GetAllFriends(context.Context, string) ([]dtos.Friend, error) {
+	return []dtos.Friend{
+		{
+			Id:    "8ae84a23-fa49-45eb-8000-bdc9b9fe074a",
+			Email: "mock@storefront-mock.com",
+		},
+	}, nil
+}
+
+// SaveMessage mocks Storefront SaveMessage() call
+func (m Mock) SaveMessage(context.Context, dtos.Message) error {
+	if m.cfg.saveMessage != nil {
+		return m.cfg.saveMessage
+	}
+
+	return nil
+}
+
+// SaveMessageResult sets the result of the mock SaveMessage()
+func SaveMessageResult(e error) Result {
+	return func(c *mockConfig) {
+		c.saveMessage = e
+	}
+}
+
+// GetMessages mocks Storefront GetMessages() call
+func (m Mock) GetMessages(ctx context.Context, conversationID string, before string, limit int) ([]dtos.Message, error) {
+	if m.cfg.getMessages != nil {
+		return make([]dtos.Message, 0), m.cfg.getMessages
+	}
+
+	return []dtos.Message{
+		{
+			Id:              "0e3b4a4e-5b7c-4c8f-9d61-0f6f5e6d7a01",
+			ConversationId:  conversationID,
+			From:            "8ae84a23-fa49-45eb-8000-bdc9b9fe074a",
+			Body:            "Hello from the storefront mock",
+			ServerTimestamp: 1700000000000,
+		},
+	}, nil
+}
+
+// GetMessagesResult sets the result of the mock GetMessages()
+func GetMessagesResult(e error) Result {
+	return func(c *mockConfig) {
+		c.getMessages = e
+	}
+}
+
+// QueueMessage mocks Storefront QueueMessage() call
+func (m Mock) QueueMessage(context.Context, string, dtos.Message) error {
+	if m.cfg.queueMessage != nil {
+		return m.cfg.queueMessage
+	}
+
+	return nil
+}
+
+// QueueMessageResult sets the result of the mock QueueMessage()
+func QueueMessageResult(e error) Result {
+	return func(c *mockConfig) {
+		c.queueMessage = e
+	}
+}
+
+// GetQueuedMessages mocks Storefront GetQueuedMessages() call
+func (m Mock) GetQueuedMessages(ctx context.Context, userID string) ([]dtos.Message, error) {
+	return []dtos.Message{
+		{
+			Id:              "0e3b4a4e-5b7c-4c8f-9d61-0f6f5e6d7a01",
+			ConversationId:  "8ae84a23-fa49-45eb-8000-bdc9b9fe074a_" + userID,
+			From:            "8ae84a23-fa49-45eb-8000-bdc9b9fe074a",
+			To:              userID,
+			Body:            "Hello from the storefront mock",
+			ServerTimestamp: 1700000000000,
+		},
+	}, nil
+}
+
+// TODO
+func (m Mock) DeleteQueuedMessage(context.Context, string, string) error {
+	return nil
+}
+
+// GetMessage mocks Storefront GetMessage() call, the message is from the first
+// user of the conversation id to the second
+func (m Mock) GetMessage(ctx context.Context, conversationID string, messageID string) (dtos.Message, error) {
+	if m.cfg.getMessage != nil {
+		return dtos.Message{}, m.cfg.getMessage
+	}
+
+	from, to, _ := strings.Cut(conversationID, "_")
+
+	return dtos.Message{
+		Id:              messageID,
+		ConversationId:  conversationID,
+		From:            from,
+		To:              to,
+		Body:            "Hello from the storefront mock",
+		ServerTimestamp: 1700000000000,
+		Status:          dtos.MessageDelivered,
+	}, nil
+}
+
+// GetMessageResult sets the result of the mock GetMessage()
+func GetMessageResult(e error) Result {
+	return func(c *mockConfig) {
+		c.getMessage = e
+	}
+}
+
+// TODO
+func (m Mock) UpdateMessageStatus(context.Context, string, string, string) (bool, error) {
+	return true, nil
+}
+
+// TODO
+func (m Mock) SavePresence(context.Context, dtos.Presence) error {
+	return nil
+}
+
+// GetPresence mocks Storefront GetPresence() call
+func (m Mock) GetPresence(ctx context.Context, userID string) (dtos.Presence, error) {
+	return dtos.Presence{
+		Id:       userID,
+		Status:   dtos.PresenceOffline,
+		LastSeen: 1700000000000,
+	}, nil
+}
+
+// CreateGroup mocks Storefront CreateGroup() call
+func (m Mock) CreateGroup(ctx context.Context, group dtos.Group) (dtos.Group, error) {
+	group.Id = "5f0d7c1e-2b8a-4f3e-9c6d-7a1b2c3d4e5f"
+
+	return group, nil
+}
+
+// GetGroup mocks Storefront GetGroup() call, the storefront mock's user is
+// the group's admin
+func (m Mock) GetGroup(ctx context.Context, id string) (dtos.Group, error) {
+	if m.cfg.getGroup != nil {
+		return dtos.Group{}, m.cfg.getGroup
+	}
+
+	return dtos.Group{
+		Id:   id,
+		Name: "Mock Group",
+		Members: map[string]string{
+			"8ae84a23-fa49-45eb-8000-bdc9b9fe074a": dtos.GroupAdmin,
+			"alice":                                dtos.GroupMember,
+			"bob":                                  dtos.GroupMember,
+		},
+	}, nil
+}
+
+// GetGroupResult sets the result of the mock GetGroup()
+func GetGroupResult(e error) Result {
+	return func(c *mockConfig) {
+		c.getGroup = e
+	}
+}
+
 // TODO
-func (m Mock) AccessTokenExists(string) error {
+func (m Mock) GetGroups(context.Context, string) ([]dtos.Group, error) {
+	return make([]dtos.Group, 0), nil
+}
+
+// TODO
+func (m Mock) RenameGroup(context.Context, string, string) error {
+	return nil
+}
+
+// TODO
+func (m Mock) SetGroupMember(context.Context, string, string, string) error {
 	return nil
 }
 
 // TODO
-func (m Mock) PostFriend(string, dtos.User) error {
+func (m Mock) RemoveGroupMember(context.Context, string, string) error {
 	return nil
 }
 
 // TODO
-func (m Mock) GetAllFriends(string) ([]dtos.Friend, error) {
-	return make([]dtos.Friend, 0), nil
+func (m Mock) DeleteGroup(context.Context, string) error {
+	return nil
+}
+
+// PostFriendRequest mocks Storefront PostFriendRequest() call
+func (m Mock) PostFriendRequest(ctx context.Context, request dtos.FriendRequest) (dtos.FriendRequest, error) {
+	if m.cfg.postFriendRequest != nil {
+		return dtos.FriendRequest{}, m.cfg.postFriendRequest
+	}
+
+	request.Id = request.From + "_" + request.To
+
+	return request, nil
+}
+
+// PostFriendRequestResult sets the result of the mock PostFriendRequest()
+func PostFriendRequestResult(e error) Result {
+	return func(c *mockConfig) {
+		c.postFriendRequest = e
+	}
+}
+
+// GetFriendRequest mocks Storefront GetFriendRequest() call, the request is
+// from the first user of the request id to the second
+func (m Mock) GetFriendRequest(ctx context.Context, id string) (dtos.FriendRequest, error) {
+	if m.cfg.getFriendRequest != nil {
+		return dtos.FriendRequest{}, m.cfg.getFriendRequest
+	}
+
+	from, to, _ := strings.Cut(id, "_")
+
+	return dtos.FriendRequest{
+		Id:        id,
+		From:      from,
+		To:        to,
+		Timestamp: 1700000000000,
+	}, nil
+}
+
+// GetFriendRequestResult sets the result of the mock GetFriendRequest()
+func GetFriendRequestResult(e error) Result {
+	return func(c *mockConfig) {
+		c.getFriendRequest = e
+	}
+}
+
+// TODO
+func (m Mock) GetFriendRequests(context.Context, string, string) ([]dtos.FriendRequest, error) {
+	return make([]dtos.FriendRequest, 0), nil
+}
+
+// TODO
+func (m Mock) AcceptFriendRequest(context.Context, string) error {
+	return nil
+}
+
+// TODO
+func (m Mock) DeleteFriendRequest(context.Context, string) error {
+	return nil
+}
+
+// DeleteFriend mocks Storefront DeleteFriend() call
+func (m Mock) DeleteFriend(context.Context, string, string) error {
+	if m.cfg.deleteFriend != nil {
+		return m.cfg.deleteFriend
+	}
+
+	return nil
+}
+
+// DeleteFriendResult sets the result of the mock DeleteFriend()
+func DeleteFriendResult(e error) Result {
+	return func(c *mockConfig) {
+		c.deleteFriend = e
+	}
+}
+
+// BlockUser mocks Storefront BlockUser() call
+func (m Mock) BlockUser(context.Context, string, dtos.BlockedUser) error {
+	if m.cfg.blockUser != nil {
+		return m.cfg.blockUser
+	}
+
+	return nil
+}
+
+// BlockUserResult sets the result of the mock BlockUser()
+func BlockUserResult(e error) Result {
+	return func(c *mockConfig) {
+		c.blockUser = e
+	}
+}
+
+// UnblockUser mocks Storefront UnblockUser() call
+func (m Mock) UnblockUser(context.Context, string, string) error {
+	if m.cfg.unblockUser != nil {
+		return m.cfg.unblockUser
+	}
+
+	return nil
+}
+
+// UnblockUserResult sets the result of the mock UnblockUser()
+func UnblockUserResult(e error) Result {
+	return func(c *mockConfig) {
+		c.unblockUser = e
+	}
+}
+
+// TODO
+func (m Mock) GetBlockedUsers(context.Context, string) ([]dtos.BlockedUser, error) {
+	return make([]dtos.BlockedUser, 0), nil
+}
+
+// IsBlocked mocks Storefront IsBlocked() call
+func (m Mock) IsBlocked(context.Context, string, string) (bool, error) {
+	return m.cfg.blocked, nil
+}
+
+// IsBlockedSucceed makes the mock IsBlocked() report users are blocked
+func IsBlockedSucceed() Result {
+	return func(c *mockConfig) {
+		c.blocked = true
+	}
 }
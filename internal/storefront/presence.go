@@ -0,0 +1,38 @@
+package storefront
+
+import (
+	"context"
+
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+
+	"github.com/mitchellh/mapstructure"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Function to store the presence of a user
+func (bkr Broker) SavePresence(ctx context.Context, presence dtos.Presence) error {
+	_, err := bkr.Firestore.Collection("presence").Doc(presence.Id).Set(ctx, presence)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Function to get the last stored presence of a user, users never seen are offline
+func (bkr Broker) GetPresence(ctx context.Context, userID string) (dtos.Presence, error) {
+	presence := dtos.Presence{}
+
+	dsnap, err := bkr.Firestore.Collection("presence").Doc(userID).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return dtos.Presence{Id: userID, Status: dtos.PresenceOffline}, nil
+		}
+		return dtos.Presence{}, err
+	}
+
+	mapstructure.Decode(dsnap.Data(), &presence)
+
+	return presence, nil
+}
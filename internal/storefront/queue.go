@@ -0,0 +1,63 @@
+package storefront
+
+import (
+	"context"
+
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+
+	"cloud.google.com/go/firestore"
+	"github.com/mitchellh/mapstructure"
+	"google.golang.org/api/iterator"
+)
+
+// Function to queue a message until the recipient acknowledges it.
+//
+// A user has one queue shared by all their devices. The first device to
+// acknowledge a message removes it, so a device that was offline until then
+// doesn't have it replayed and catches up from the conversation history. A
+// device connecting is replayed every message still queued, including those
+// its other devices already received, so clients dedupe messages by their ID.
+func (bkr Broker) QueueMessage(ctx context.Context, userID string, message dtos.Message) error {
+	_, err := bkr.Firestore.Collection("users").Doc(userID).Collection("queue").Doc(message.Id).Set(ctx, message)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Function to get the messages queued for a user, oldest first
+func (bkr Broker) GetQueuedMessages(ctx context.Context, userID string) ([]dtos.Message, error) {
+	messages := make([]dtos.Message, 0)
+
+	iter := bkr.Firestore.Collection("users").Doc(userID).Collection("queue").OrderBy("serverTimestamp", firestore.Asc).Documents(ctx)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+
+		if err != nil {
+			return make([]dtos.Message, 0), err
+		}
+
+		if doc.Data() != nil {
+			message := dtos.Message{}
+
+			mapstructure.Decode(doc.Data(), &message)
+			messages = append(messages, message)
+		}
+	}
+
+	return messages, nil
+}
+
+// Function to remove an acknowledged message from a user's queue
+func (bkr Broker) DeleteQueuedMessage(ctx context.Context, userID string, messageID string) error {
+	_, err := bkr.Firestore.Collection("users").Doc(userID).Collection("queue").Doc(messageID).Delete(ctx)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,256 @@
+package storefront
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// SQL is a Storefront storing its data in a SQLite or PostgreSQL database,
+// with the same semantics as the Firestore storefront.
+type SQL struct {
+	db      *sql.DB
+	backend string // BackendSQLite or BackendPostgres
+
+	cost int // bcrypt cost of user passwords
+}
+
+// Schema migrations, applied in order and recorded in schema_migrations. Only
+// ever append to this list, statements must run on both SQLite and PostgreSQL.
+var migrations = [][]string{
+	{
+		`CREATE TABLE users (
+			id TEXT PRIMARY KEY,
+			email TEXT NOT NULL UNIQUE,
+			provider TEXT NOT NULL,
+			password TEXT NOT NULL DEFAULT ''
+		)`,
+		`CREATE TABLE friends (
+			user_id TEXT NOT NULL,
+			friend_id TEXT NOT NULL,
+			email TEXT NOT NULL,
+			PRIMARY KEY (user_id, friend_id)
+		)`,
+		`CREATE TABLE tokens (
+			token TEXT PRIMARY KEY,
+			token_type TEXT NOT NULL,
+			user_id TEXT NOT NULL,
+			email TEXT NOT NULL,
+			provider TEXT NOT NULL
+		)`,
+	},
+	{
+		`CREATE TABLE messages (
+			conversation_id TEXT NOT NULL,
+			id TEXT NOT NULL,
+			from_id TEXT NOT NULL,
+			to_id TEXT NOT NULL DEFAULT '',
+			group_id TEXT NOT NULL DEFAULT '',
+			body TEXT NOT NULL,
+			timestamp BIGINT NOT NULL DEFAULT 0,
+			server_timestamp BIGINT NOT NULL,
+			status TEXT NOT NULL,
+			PRIMARY KEY (conversation_id, id)
+		)`,
+		`CREATE INDEX messages_by_time ON messages (conversation_id, server_timestamp, id)`,
+		`CREATE TABLE queued_messages (
+			user_id TEXT NOT NULL,
+			id TEXT NOT NULL,
+			conversation_id TEXT NOT NULL,
+			from_id TEXT NOT NULL,
+			to_id TEXT NOT NULL DEFAULT '',
+			group_id TEXT NOT NULL DEFAULT '',
+			body TEXT NOT NULL,
+			timestamp BIGINT NOT NULL DEFAULT 0,
+			server_timestamp BIGINT NOT NULL,
+			status TEXT NOT NULL,
+			PRIMARY KEY (user_id, id)
+		)`,
+		`CREATE TABLE presence (
+			id TEXT PRIMARY KEY,
+			status TEXT NOT NULL,
+			last_seen BIGINT NOT NULL DEFAULT 0
+		)`,
+	},
+	{
+		`CREATE TABLE chat_groups (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL
+		)`,
+		`CREATE TABLE group_members (
+			group_id TEXT NOT NULL REFERENCES chat_groups (id) ON DELETE CASCADE,
+			user_id TEXT NOT NULL,
+			role TEXT NOT NULL,
+			PRIMARY KEY (group_id, user_id)
+		)`,
+		`CREATE INDEX group_members_by_user ON group_members (user_id)`,
+	},
+	{
+		`CREATE TABLE friend_requests (
+			id TEXT PRIMARY KEY,
+			from_id TEXT NOT NULL,
+			to_id TEXT NOT NULL,
+			timestamp BIGINT NOT NULL DEFAULT 0
+		)`,
+		`CREATE INDEX friend_requests_by_from ON friend_requests (from_id, timestamp)`,
+		`CREATE INDEX friend_requests_by_to ON friend_requests (to_id, timestamp)`,
+		`CREATE TABLE blocked_users (
+			user_id TEXT NOT NULL,
+			blocked_id TEXT NOT NULL,
+			timestamp BIGINT NOT NULL DEFAULT 0,
+			PRIMARY KEY (user_id, blocked_id)
+		)`,
+	},
+	{
+		`CREATE TABLE refresh_tokens (
+			token TEXT PRIMARY KEY,
+			family_id TEXT NOT NULL,
+			user_id TEXT NOT NULL,
+			email TEXT NOT NULL,
+			provider TEXT NOT NULL,
+			expires_at BIGINT NOT NULL,
+			used BOOLEAN NOT NULL DEFAULT FALSE,
+			revoked BOOLEAN NOT NULL DEFAULT FALSE
+		)`,
+		`CREATE INDEX refresh_tokens_by_family ON refresh_tokens (family_id)`,
+	},
+	{
+		`ALTER TABLE tokens ADD COLUMN family_id TEXT NOT NULL DEFAULT ''`,
+	},
+	{
+		`CREATE INDEX tokens_by_family ON tokens (family_id)`,
+	},
+}
+
+// Open a SQLite or PostgreSQL storefront and bring its schema up to date
+func NewSQL(backend string, dsn string) (*SQL, error) {
+	driver := "postgres"
+	if backend == BackendSQLite {
+		driver = "sqlite"
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %w", err)
+	}
+
+	if backend == BackendSQLite {
+		// SQLite allows a single writer, and every connection to an in-memory
+		// database opens a different database
+		db.SetMaxOpenConns(1)
+
+		_, err = db.Exec("PRAGMA foreign_keys = ON")
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("error enabling foreign keys: %w", err)
+		}
+	}
+
+	r := &SQL{db: db, backend: backend, cost: bcrypt.DefaultCost}
+
+	err = r.migrate()
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error migrating database: %w", err)
+	}
+
+	return r, nil
+}
+
+// Close the database
+func (s *SQL) Close() error {
+	return s.db.Close()
+}
+
+// Apply the migrations the database has not seen yet
+func (s *SQL) migrate() error {
+	_, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`)
+	if err != nil {
+		return err
+	}
+
+	var version int
+	err = s.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	if err != nil {
+		return err
+	}
+
+	for i := version; i < len(migrations); i++ {
+		err = s.inTx(context.Background(), func(tx *sql.Tx) error {
+			for _, statement := range migrations[i] {
+				if _, err := tx.Exec(statement); err != nil {
+					return err
+				}
+			}
+
+			_, err := tx.Exec(s.rebind(`INSERT INTO schema_migrations (version) VALUES (?)`), i+1)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// Run a function in a transaction, committing it unless the function fails
+func (s *SQL) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = fn(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Check if an error is a row conflicting with another on a unique column,
+// e.g. when two requests insert the same value at once
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code.Name() == "unique_violation"
+	}
+
+	return false
+}
+
+// Rewrite the ? placeholders of a query to the backend's syntax
+func (s *SQL) rebind(query string) string {
+	if s.backend != BackendPostgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,851 @@
+package storefront
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Columns of a message, in the order scanMessage reads them
+const messageColumns = `id, conversation_id, from_id, to_id, group_id, body, timestamp, server_timestamp, status`
+
+// rowScanner is a *sql.Row or *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanMessage(row rowScanner) (dtos.Message, error) {
+	message := dtos.Message{}
+
+	err := row.Scan(&message.Id, &message.ConversationId, &message.From, &message.To, &message.GroupId, &message.Body, &message.Timestamp, &message.ServerTimestamp, &message.Status)
+
+	return message, err
+}
+
+func (s *SQL) GetUser(ctx context.Context, id string) (dtos.User, error) {
+	user := dtos.User{}
+
+	err := s.db.QueryRowContext(ctx, s.rebind(`SELECT id, email, provider FROM users WHERE id = ?`), id).Scan(&user.Id, &user.Email, &user.Provider)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dtos.User{}, ErrUserNotFound
+		}
+		return dtos.User{}, err
+	}
+
+	return user, nil
+}
+
+func (s *SQL) GetUserByEmail(ctx context.Context, email string) (dtos.User, error) {
+	user := dtos.User{}
+
+	err := s.db.QueryRowContext(ctx, s.rebind(`SELECT id, email, provider FROM users WHERE email = ?`), email).Scan(&user.Id, &user.Email, &user.Provider)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dtos.User{}, ErrUserNotFound
+		}
+		return dtos.User{}, err
+	}
+
+	return user, nil
+}
+
+func (s *SQL) GetAllFriends(ctx context.Context, id string) ([]dtos.Friend, error) {
+	friends := make([]dtos.Friend, 0)
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(`SELECT friend_id, email FROM friends WHERE user_id = ? ORDER BY friend_id`), id)
+	if err != nil {
+		return make([]dtos.Friend, 0), err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		friend := dtos.Friend{}
+
+		if err := rows.Scan(&friend.Id, &friend.Email); err != nil {
+			return make([]dtos.Friend, 0), err
+		}
+		friends = append(friends, friend)
+	}
+
+	if err := rows.Err(); err != nil {
+		return make([]dtos.Friend, 0), err
+	}
+
+	return friends, nil
+}
+
+// Sign a user in, checking password
+func (s *SQL) SignIn(ctx context.Context, userInfo dtos.User) error {
+	var hash string
+
+	err := s.db.QueryRowContext(ctx, s.rebind(`SELECT password FROM users WHERE email = ?`), userInfo.Email).Scan(&hash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	// Validate the request password is the same as the hash password
+	err = bcrypt.CompareHashAndPassword([]byte(hash), []byte(userInfo.Password))
+	if err != nil {
+		return ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (s *SQL) PostUser(ctx context.Context, userInfo dtos.User) (dtos.User, error) {
+	user := dtos.User{
+		Id:       uuid.New().String(),
+		Email:    userInfo.Email,
+		Provider: userInfo.Provider,
+	}
+
+	if userInfo.Provider == "Flutter" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(userInfo.Password), s.cost)
+		if err != nil {
+			return dtos.User{}, err
+		}
+
+		user.Password = string(hash)
+	}
+
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		// Check if the email already exists
+		var exists int
+		err := tx.QueryRowContext(ctx, s.rebind(`SELECT COUNT(*) FROM users WHERE email = ?`), user.Email).Scan(&exists)
+		if err != nil {
+			return err
+		}
+
+		if exists > 0 {
+			return ErrEmailTaken
+		}
+
+		// The check doesn't lock anything, so a user created with the same email
+		// since then still fails the insert
+		_, err = tx.ExecContext(ctx, s.rebind(`INSERT INTO users (id, email, provider, password) VALUES (?, ?, ?, ?)`), user.Id, user.Email, user.Provider, user.Password)
+		if isUniqueViolation(err) {
+			return ErrEmailTaken
+		}
+		return err
+	})
+	if err != nil {
+		return dtos.User{}, err
+	}
+
+	return user, nil
+}
+
+// Check if a user has added another user as a friend
+func (s *SQL) IsFriend(ctx context.Context, userID string, friendID string) (bool, error) {
+	return s.exists(ctx, `SELECT COUNT(*) FROM friends WHERE user_id = ? AND friend_id = ?`, userID, friendID)
+}
+
+// Function to check if an access token exists, by its ID
+func (s *SQL) AccessTokenExists(ctx context.Context, tokenID string) error {
+	exists, err := s.exists(ctx, `SELECT COUNT(*) FROM tokens WHERE token = ?`, tokenID)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return ErrTokenNotFound
+	}
+
+	return nil
+}
+
+// Function to get the access token with an ID
+func (s *SQL) GetAccessToken(ctx context.Context, tokenID string) (TokenInfo, error) {
+	info := TokenInfo{}
+
+	err := s.db.QueryRowContext(ctx, s.rebind(`SELECT token_type, user_id, email, provider, family_id FROM tokens WHERE token = ?`), tokenID).
+		Scan(&info.TokenType, &info.Id, &info.Email, &info.Provider, &info.FamilyId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return TokenInfo{}, ErrTokenNotFound
+		}
+		return TokenInfo{}, err
+	}
+
+	return info, nil
+}
+
+// Function to delete the access token with an ID, revoking it
+func (s *SQL) DeleteAccessToken(ctx context.Context, tokenID string) error {
+	_, err := s.db.ExecContext(ctx, s.rebind(`DELETE FROM tokens WHERE token = ?`), tokenID)
+
+	return err
+}
+
+// Function to add the access token with an ID, along with the refresh token
+// family issued with it
+func (s *SQL) AddAccessToken(ctx context.Context, tokenID string, user dtos.User, familyID string) error {
+	_, err := s.db.ExecContext(ctx, s.rebind(`INSERT INTO tokens (token, token_type, user_id, email, provider, family_id) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (token) DO UPDATE SET token_type = excluded.token_type, user_id = excluded.user_id, email = excluded.email, provider = excluded.provider,
+			family_id = excluded.family_id`),
+		tokenID, "user", user.Id, user.Email, user.Provider, familyID)
+
+	return err
+}
+
+// Function to add a refresh token
+func (s *SQL) AddRefreshToken(ctx context.Context, token string, info RefreshToken) error {
+	_, err := s.db.ExecContext(ctx, s.rebind(`INSERT INTO refresh_tokens (token, family_id, user_id, email, provider, expires_at, used, revoked) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (token) DO UPDATE SET family_id = excluded.family_id, user_id = excluded.user_id, email = excluded.email, provider = excluded.provider,
+			expires_at = excluded.expires_at, used = excluded.used, revoked = excluded.revoked`),
+		refreshTokenKey(token), info.FamilyId, info.UserId, info.Email, info.Provider, info.ExpiresAt, info.Used, info.Revoked)
+
+	return err
+}
+
+// Function to exchange a refresh token, marking it used so that it can only
+// be exchanged once. Presenting a used token again revokes its whole family
+// and deletes the access tokens issued with it, whose IDs are returned.
+func (s *SQL) UseRefreshToken(ctx context.Context, token string) (RefreshToken, []string, error) {
+	key := refreshTokenKey(token)
+
+	info := RefreshToken{}
+	reused := false
+	var tokenIDs []string
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, s.rebind(`SELECT family_id, user_id, email, provider, expires_at, used, revoked FROM refresh_tokens WHERE token = ?`), key).
+			Scan(&info.FamilyId, &info.UserId, &info.Email, &info.Provider, &info.ExpiresAt, &info.Used, &info.Revoked)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrTokenNotFound
+			}
+			return err
+		}
+
+		err = refreshTokenError(info, time.Now())
+		if err != nil && !errors.Is(err, ErrRefreshTokenReused) {
+			return err
+		}
+
+		if err == nil {
+			// Only one of concurrent exchanges of the token marks it used
+			res, err := tx.ExecContext(ctx, s.rebind(`UPDATE refresh_tokens SET used = ? WHERE token = ? AND used = ?`), true, key, false)
+			if err != nil {
+				return err
+			}
+
+			n, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				return nil
+			}
+		}
+
+		// The revocation must be committed, so the transaction succeeds
+		reused = true
+		_, err = tx.ExecContext(ctx, s.rebind(`UPDATE refresh_tokens SET revoked = ? WHERE family_id = ?`), true, info.FamilyId)
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.QueryContext(ctx, s.rebind(`SELECT token FROM tokens WHERE family_id = ?`), info.FamilyId)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var tokenID string
+			if err := rows.Scan(&tokenID); err != nil {
+				return err
+			}
+			tokenIDs = append(tokenIDs, tokenID)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		rows.Close()
+
+		_, err = tx.ExecContext(ctx, s.rebind(`DELETE FROM tokens WHERE family_id = ?`), info.FamilyId)
+		return err
+	})
+	if err != nil {
+		return RefreshToken{}, nil, err
+	}
+
+	if reused {
+		return RefreshToken{}, tokenIDs, ErrRefreshTokenReused
+	}
+
+	info.Used = true
+	return info, nil, nil
+}
+
+// Function to revoke every refresh token of a family
+func (s *SQL) RevokeRefreshTokens(ctx context.Context, familyID string) error {
+	_, err := s.db.ExecContext(ctx, s.rebind(`UPDATE refresh_tokens SET revoked = ? WHERE family_id = ?`), true, familyID)
+
+	return err
+}
+
+// Function to store a message in its conversation
+func (s *SQL) SaveMessage(ctx context.Context, message dtos.Message) error {
+	_, err := s.db.ExecContext(ctx, s.rebind(`INSERT INTO messages (`+messageColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (conversation_id, id) DO UPDATE SET from_id = excluded.from_id, to_id = excluded.to_id, group_id = excluded.group_id, body = excluded.body,
+			timestamp = excluded.timestamp, server_timestamp = excluded.server_timestamp, status = excluded.status`),
+		message.Id, message.ConversationId, message.From, message.To, message.GroupId, message.Body, message.Timestamp, message.ServerTimestamp, message.Status)
+
+	return err
+}
+
+// Function to list the messages of a conversation, newest first, starting after the message id before
+func (s *SQL) GetMessages(ctx context.Context, conversationID string, before string, limit int) ([]dtos.Message, error) {
+	messages := make([]dtos.Message, 0)
+
+	query := `SELECT ` + messageColumns + ` FROM messages WHERE conversation_id = ?`
+	args := []any{conversationID}
+
+	if before != "" {
+		var serverTimestamp int64
+
+		err := s.db.QueryRowContext(ctx, s.rebind(`SELECT server_timestamp FROM messages WHERE conversation_id = ? AND id = ?`), conversationID, before).Scan(&serverTimestamp)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return make([]dtos.Message, 0), ErrMessageNotFound
+			}
+			return make([]dtos.Message, 0), err
+		}
+
+		query += ` AND (server_timestamp < ? OR (server_timestamp = ? AND id < ?))`
+		args = append(args, serverTimestamp, serverTimestamp, before)
+	}
+
+	query += ` ORDER BY server_timestamp DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(query), args...)
+	if err != nil {
+		return make([]dtos.Message, 0), err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		message, err := scanMessage(rows)
+		if err != nil {
+			return make([]dtos.Message, 0), err
+		}
+		messages = append(messages, message)
+	}
+
+	if err := rows.Err(); err != nil {
+		return make([]dtos.Message, 0), err
+	}
+
+	return messages, nil
+}
+
+// Function to get a message of a conversation
+func (s *SQL) GetMessage(ctx context.Context, conversationID string, messageID string) (dtos.Message, error) {
+	message, err := scanMessage(s.db.QueryRowContext(ctx, s.rebind(`SELECT `+messageColumns+` FROM messages WHERE conversation_id = ? AND id = ?`), conversationID, messageID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dtos.Message{}, ErrMessageNotFound
+		}
+		return dtos.Message{}, err
+	}
+
+	return message, nil
+}
+
+// Function to move a message's status forward, reporting whether it changed
+func (s *SQL) UpdateMessageStatus(ctx context.Context, conversationID string, messageID string, messageStatus string) (bool, error) {
+	if _, ok := messageStatusOrder[messageStatus]; !ok {
+		return false, ErrInvalidStatus
+	}
+
+	updated := false
+
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		var current string
+
+		err := tx.QueryRowContext(ctx, s.rebind(`SELECT status FROM messages WHERE conversation_id = ? AND id = ?`), conversationID, messageID).Scan(&current)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrMessageNotFound
+			}
+			return err
+		}
+
+		updated = messageStatusOrder[messageStatus] > messageStatusOrder[current]
+		if !updated {
+			return nil
+		}
+
+		_, err = tx.ExecContext(ctx, s.rebind(`UPDATE messages SET status = ? WHERE conversation_id = ? AND id = ?`), messageStatus, conversationID, messageID)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return updated, nil
+}
+
+// Function to queue a message until the recipient acknowledges it
+func (s *SQL) QueueMessage(ctx context.Context, userID string, message dtos.Message) error {
+	_, err := s.db.ExecContext(ctx, s.rebind(`INSERT INTO queued_messages (user_id, `+messageColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, id) DO UPDATE SET conversation_id = excluded.conversation_id, from_id = excluded.from_id, to_id = excluded.to_id, group_id = excluded.group_id,
+			body = excluded.body, timestamp = excluded.timestamp, server_timestamp = excluded.server_timestamp, status = excluded.status`),
+		userID, message.Id, message.ConversationId, message.From, message.To, message.GroupId, message.Body, message.Timestamp, message.ServerTimestamp, message.Status)
+
+	return err
+}
+
+// Function to get the messages queued for a user, oldest first
+func (s *SQL) GetQueuedMessages(ctx context.Context, userID string) ([]dtos.Message, error) {
+	messages := make([]dtos.Message, 0)
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(`SELECT `+messageColumns+` FROM queued_messages WHERE user_id = ? ORDER BY server_timestamp, id`), userID)
+	if err != nil {
+		return make([]dtos.Message, 0), err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		message, err := scanMessage(rows)
+		if err != nil {
+			return make([]dtos.Message, 0), err
+		}
+		messages = append(messages, message)
+	}
+
+	if err := rows.Err(); err != nil {
+		return make([]dtos.Message, 0), err
+	}
+
+	return messages, nil
+}
+
+// Function to remove an acknowledged message from a user's queue
+func (s *SQL) DeleteQueuedMessage(ctx context.Context, userID string, messageID string) error {
+	_, err := s.db.ExecContext(ctx, s.rebind(`DELETE FROM queued_messages WHERE user_id = ? AND id = ?`), userID, messageID)
+
+	return err
+}
+
+// Function to store the presence of a user
+func (s *SQL) SavePresence(ctx context.Context, presence dtos.Presence) error {
+	_, err := s.db.ExecContext(ctx, s.rebind(`INSERT INTO presence (id, status, last_seen) VALUES (?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET status = excluded.status, last_seen = excluded.last_seen`),
+		presence.Id, presence.Status, presence.LastSeen)
+
+	return err
+}
+
+// Function to get the last stored presence of a user, users never seen are offline
+func (s *SQL) GetPresence(ctx context.Context, userID string) (dtos.Presence, error) {
+	presence := dtos.Presence{}
+
+	err := s.db.QueryRowContext(ctx, s.rebind(`SELECT id, status, last_seen FROM presence WHERE id = ?`), userID).Scan(&presence.Id, &presence.Status, &presence.LastSeen)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dtos.Presence{Id: userID, Status: dtos.PresenceOffline}, nil
+		}
+		return dtos.Presence{}, err
+	}
+
+	return presence, nil
+}
+
+// Function to create a new group
+func (s *SQL) CreateGroup(ctx context.Context, groupInfo dtos.Group) (dtos.Group, error) {
+	group := dtos.Group{
+		Id:      uuid.New().String(),
+		Name:    groupInfo.Name,
+		Members: groupInfo.Members,
+	}
+
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, s.rebind(`INSERT INTO chat_groups (id, name) VALUES (?, ?)`), group.Id, group.Name)
+		if err != nil {
+			return err
+		}
+
+		for userID, role := range group.Members {
+			_, err = tx.ExecContext(ctx, s.rebind(`INSERT INTO group_members (group_id, user_id, role) VALUES (?, ?, ?)`), group.Id, userID, role)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return dtos.Group{}, err
+	}
+
+	return group, nil
+}
+
+// Function to get a group
+func (s *SQL) GetGroup(ctx context.Context, id string) (dtos.Group, error) {
+	groups, err := s.queryGroups(ctx, `SELECT g.id, g.name, m.user_id, m.role FROM chat_groups g LEFT JOIN group_members m ON m.group_id = g.id WHERE g.id = ?`, id)
+	if err != nil {
+		return dtos.Group{}, err
+	}
+
+	if len(groups) == 0 {
+		return dtos.Group{}, ErrGroupNotFound
+	}
+
+	return groups[0], nil
+}
+
+// Function to get all the groups a user is a member of
+func (s *SQL) GetGroups(ctx context.Context, userID string) ([]dtos.Group, error) {
+	groups, err := s.queryGroups(ctx, `SELECT g.id, g.name, m.user_id, m.role FROM chat_groups g JOIN group_members m ON m.group_id = g.id
+		WHERE g.id IN (SELECT group_id FROM group_members WHERE user_id = ?) ORDER BY g.id`, userID)
+	if err != nil {
+		return make([]dtos.Group, 0), err
+	}
+
+	return groups, nil
+}
+
+// Function to rename a group
+func (s *SQL) RenameGroup(ctx context.Context, id string, name string) error {
+	return s.updateGroup(ctx, id, `UPDATE chat_groups SET name = ? WHERE id = ?`, name, id)
+}
+
+// Function to add a member to a group, or change the role of an existing member
+func (s *SQL) SetGroupMember(ctx context.Context, id string, userID string, role string) error {
+	return s.updateGroup(ctx, id, `INSERT INTO group_members (group_id, user_id, role) VALUES (?, ?, ?)
+		ON CONFLICT (group_id, user_id) DO UPDATE SET role = excluded.role`, id, userID, role)
+}
+
+// Function to remove a member from a group
+func (s *SQL) RemoveGroupMember(ctx context.Context, id string, userID string) error {
+	return s.updateGroup(ctx, id, `DELETE FROM group_members WHERE group_id = ? AND user_id = ?`, id, userID)
+}
+
+// Function to delete a group
+func (s *SQL) DeleteGroup(ctx context.Context, id string) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, s.rebind(`DELETE FROM group_members WHERE group_id = ?`), id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, s.rebind(`DELETE FROM chat_groups WHERE id = ?`), id)
+		return err
+	})
+}
+
+// Apply an update to an existing group
+func (s *SQL) updateGroup(ctx context.Context, id string, query string, args ...any) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		var exists int
+		err := tx.QueryRowContext(ctx, s.rebind(`SELECT COUNT(*) FROM chat_groups WHERE id = ?`), id).Scan(&exists)
+		if err != nil {
+			return err
+		}
+
+		if exists == 0 {
+			return ErrGroupNotFound
+		}
+
+		_, err = tx.ExecContext(ctx, s.rebind(query), args...)
+		return err
+	})
+}
+
+// Read groups from rows of group id, name, member id and role
+func (s *SQL) queryGroups(ctx context.Context, query string, args ...any) ([]dtos.Group, error) {
+	groups := make([]dtos.Group, 0)
+	index := make(map[string]int)
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, name string
+		var userID, role sql.NullString
+
+		if err := rows.Scan(&id, &name, &userID, &role); err != nil {
+			return nil, err
+		}
+
+		i, ok := index[id]
+		if !ok {
+			i = len(groups)
+			index[id] = i
+			groups = append(groups, dtos.Group{Id: id, Name: name, Members: make(map[string]string)})
+		}
+
+		if userID.Valid {
+			groups[i].Members[userID.String] = role.String
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+// Function to send a friend request from one user to another
+func (s *SQL) PostFriendRequest(ctx context.Context, requestInfo dtos.FriendRequest) (dtos.FriendRequest, error) {
+	request := dtos.FriendRequest{
+		Id:        FriendRequestID(requestInfo.From, requestInfo.To),
+		From:      requestInfo.From,
+		To:        requestInfo.To,
+		Timestamp: requestInfo.Timestamp,
+	}
+
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		checks := []struct {
+			query string
+			args  []any
+			err   error
+		}{
+			{`SELECT COUNT(*) FROM friends WHERE user_id = ? AND friend_id = ?`, []any{request.From, request.To}, ErrFriendAlreadyAdded},
+			{`SELECT COUNT(*) FROM friend_requests WHERE id = ?`, []any{request.Id}, ErrFriendRequestSent},
+			{`SELECT COUNT(*) FROM friend_requests WHERE id = ?`, []any{FriendRequestID(request.To, request.From)}, ErrFriendRequestReceived},
+		}
+
+		for _, check := range checks {
+			var exists int
+			if err := tx.QueryRowContext(ctx, s.rebind(check.query), check.args...).Scan(&exists); err != nil {
+				return err
+			}
+
+			if exists > 0 {
+				return check.err
+			}
+		}
+
+		_, err := tx.ExecContext(ctx, s.rebind(`INSERT INTO friend_requests (id, from_id, to_id, timestamp) VALUES (?, ?, ?, ?)`), request.Id, request.From, request.To, request.Timestamp)
+		return err
+	})
+	if err != nil {
+		return dtos.FriendRequest{}, err
+	}
+
+	return request, nil
+}
+
+// Function to get a friend request
+func (s *SQL) GetFriendRequest(ctx context.Context, id string) (dtos.FriendRequest, error) {
+	request := dtos.FriendRequest{}
+
+	err := s.db.QueryRowContext(ctx, s.rebind(`SELECT id, from_id, to_id, timestamp FROM friend_requests WHERE id = ?`), id).Scan(&request.Id, &request.From, &request.To, &request.Timestamp)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dtos.FriendRequest{}, ErrFriendRequestNotFound
+		}
+		return dtos.FriendRequest{}, err
+	}
+
+	return request, nil
+}
+
+// Function to get the pending friend requests received or sent by a user,
+// oldest first
+func (s *SQL) GetFriendRequests(ctx context.Context, userID string, direction string) ([]dtos.FriendRequest, error) {
+	requests := make([]dtos.FriendRequest, 0)
+
+	column := "to_id"
+	switch direction {
+	case dtos.FriendRequestIncoming:
+	case dtos.FriendRequestOutgoing:
+		column = "from_id"
+	default:
+		return requests, ErrInvalidDirection
+	}
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(`SELECT id, from_id, to_id, timestamp FROM friend_requests WHERE `+column+` = ? ORDER BY timestamp, id`), userID)
+	if err != nil {
+		return make([]dtos.FriendRequest, 0), err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		request := dtos.FriendRequest{}
+
+		if err := rows.Scan(&request.Id, &request.From, &request.To, &request.Timestamp); err != nil {
+			return make([]dtos.FriendRequest, 0), err
+		}
+		requests = append(requests, request)
+	}
+
+	if err := rows.Err(); err != nil {
+		return make([]dtos.FriendRequest, 0), err
+	}
+
+	return requests, nil
+}
+
+// Function to accept a friend request, adding each user to the other's friends
+// and removing the request in a single transaction
+func (s *SQL) AcceptFriendRequest(ctx context.Context, id string) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		request := dtos.FriendRequest{}
+
+		err := tx.QueryRowContext(ctx, s.rebind(`SELECT from_id, to_id FROM friend_requests WHERE id = ?`), id).Scan(&request.From, &request.To)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrFriendRequestNotFound
+			}
+			return err
+		}
+
+		emails := make(map[string]string, 2)
+		for _, userID := range []string{request.From, request.To} {
+			var email string
+
+			err := tx.QueryRowContext(ctx, s.rebind(`SELECT email FROM users WHERE id = ?`), userID).Scan(&email)
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return ErrUserNotFound
+				}
+				return err
+			}
+
+			emails[userID] = email
+		}
+
+		for _, pair := range [][2]string{{request.From, request.To}, {request.To, request.From}} {
+			_, err := tx.ExecContext(ctx, s.rebind(`INSERT INTO friends (user_id, friend_id, email) VALUES (?, ?, ?)
+				ON CONFLICT (user_id, friend_id) DO UPDATE SET email = excluded.email`), pair[0], pair[1], emails[pair[1]])
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, s.rebind(`DELETE FROM friend_requests WHERE id = ?`), id)
+		return err
+	})
+}
+
+// Function to delete a friend request, when it is declined or cancelled
+func (s *SQL) DeleteFriendRequest(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, s.rebind(`DELETE FROM friend_requests WHERE id = ?`), id)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrFriendRequestNotFound
+	}
+
+	return nil
+}
+
+// Function to remove a friendship on both sides
+func (s *SQL) DeleteFriend(ctx context.Context, userID string, friendID string) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, s.rebind(`DELETE FROM friends WHERE user_id = ? AND friend_id = ?`), userID, friendID)
+		if err != nil {
+			return err
+		}
+
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return ErrFriendNotFound
+		}
+
+		_, err = tx.ExecContext(ctx, s.rebind(`DELETE FROM friends WHERE user_id = ? AND friend_id = ?`), friendID, userID)
+		return err
+	})
+}
+
+// Function to block a user, which also removes any friendship or pending
+// friend request between the two users
+func (s *SQL) BlockUser(ctx context.Context, userID string, blocked dtos.BlockedUser) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		statements := []struct {
+			query string
+			args  []any
+		}{
+			{`INSERT INTO blocked_users (user_id, blocked_id, timestamp) VALUES (?, ?, ?)
+				ON CONFLICT (user_id, blocked_id) DO UPDATE SET timestamp = excluded.timestamp`, []any{userID, blocked.Id, blocked.Timestamp}},
+			{`DELETE FROM friends WHERE (user_id = ? AND friend_id = ?) OR (user_id = ? AND friend_id = ?)`, []any{userID, blocked.Id, blocked.Id, userID}},
+			{`DELETE FROM friend_requests WHERE id = ? OR id = ?`, []any{FriendRequestID(userID, blocked.Id), FriendRequestID(blocked.Id, userID)}},
+		}
+
+		for _, statement := range statements {
+			if _, err := tx.ExecContext(ctx, s.rebind(statement.query), statement.args...); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Function to unblock a user
+func (s *SQL) UnblockUser(ctx context.Context, userID string, blockedID string) error {
+	res, err := s.db.ExecContext(ctx, s.rebind(`DELETE FROM blocked_users WHERE user_id = ? AND blocked_id = ?`), userID, blockedID)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrUserNotBlocked
+	}
+
+	return nil
+}
+
+// Function to get the users a user has blocked
+func (s *SQL) GetBlockedUsers(ctx context.Context, userID string) ([]dtos.BlockedUser, error) {
+	blocked := make([]dtos.BlockedUser, 0)
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(`SELECT blocked_id, timestamp FROM blocked_users WHERE user_id = ? ORDER BY blocked_id`), userID)
+	if err != nil {
+		return make([]dtos.BlockedUser, 0), err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user := dtos.BlockedUser{}
+
+		if err := rows.Scan(&user.Id, &user.Timestamp); err != nil {
+			return make([]dtos.BlockedUser, 0), err
+		}
+		blocked = append(blocked, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return make([]dtos.BlockedUser, 0), err
+	}
+
+	return blocked, nil
+}
+
+// Check if a user has blocked another user
+func (s *SQL) IsBlocked(ctx context.Context, userID string, blockedID string) (bool, error) {
+	return s.exists(ctx, `SELECT COUNT(*) FROM blocked_users WHERE user_id = ? AND blocked_id = ?`, userID, blockedID)
+}
+
+// Run a COUNT(*) query, reporting whether it counted any row
+func (s *SQL) exists(ctx context.Context, query string, args ...any) (bool, error) {
+	var count int
+
+	err := s.db.QueryRowContext(ctx, s.rebind(query), args...).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
@@ -0,0 +1,67 @@
+package storefront
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+)
+
+func TestSQLiteMigrations(t *testing.T) {
+	t.Parallel()
+
+	dsn := filepath.Join(t.TempDir(), "storefront.db")
+
+	store, err := NewSQL(BackendSQLite, dsn)
+	if err != nil {
+		t.Fatalf("couldn't open database: %s", err.Error())
+	}
+
+	user, err := store.PostUser(context.Background(), dtos.User{Email: "alice@example.com", Provider: "Google"})
+	if err != nil {
+		t.Fatalf("couldn't create user: %s", err.Error())
+	}
+	store.Close()
+
+	// Reopening an up to date database keeps its data
+	store, err = NewSQL(BackendSQLite, dsn)
+	if err != nil {
+		t.Fatalf("couldn't reopen database: %s", err.Error())
+	}
+	defer store.Close()
+
+	found, err := store.GetUser(context.Background(), user.Id)
+	if err != nil || found.Email != user.Email {
+		t.Fatalf("expected to find the user after reopening but got %+v (%v)", found, err)
+	}
+}
+
+func TestSQLiteUniqueViolation(t *testing.T) {
+	t.Parallel()
+
+	store, err := NewSQL(BackendSQLite, ":memory:")
+	if err != nil {
+		t.Fatalf("couldn't open database: %s", err.Error())
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	insert := `INSERT INTO users (id, email, provider) VALUES (?, ?, ?)`
+
+	_, err = store.db.ExecContext(ctx, insert, "first-id", "alice@example.com", "Google")
+	if err != nil {
+		t.Fatalf("couldn't insert user: %s", err.Error())
+	}
+
+	// A user inserted between the email check and the insert of PostUser
+	_, err = store.db.ExecContext(ctx, insert, "second-id", "alice@example.com", "Google")
+	if !isUniqueViolation(err) {
+		t.Fatalf("expected a unique violation but got %v", err)
+	}
+
+	if isUniqueViolation(errors.New("some error")) || isUniqueViolation(nil) {
+		t.Fatalf("expected other errors not to be unique violations")
+	}
+}
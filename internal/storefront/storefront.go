@@ -1,6 +1,7 @@
 package storefront
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
@@ -10,15 +11,45 @@ import (
 
 // Storefront exposes all functionalities of the Storefront service.
 type Storefront interface {
-	GetUser(string) (dtos.User, error)
-	GetUserByEmail(string) (dtos.User, error)
-	GetAllFriends(string) ([]dtos.Friend, error)
-	SignIn(dtos.User) error
-	PostUser(dtos.User) (dtos.User, error)
-	PostFriend(string, dtos.User) error
-	DeleteAccessToken(string) error
-	AccessTokenExists(string) error
-	AddAccessToken(string, dtos.User) error
+	GetUser(context.Context, string) (dtos.User, error)
+	GetUserByEmail(context.Context, string) (dtos.User, error)
+	GetAllFriends(context.Context, string) ([]dtos.Friend, error)
+	SignIn(context.Context, dtos.User) error
+	PostUser(context.Context, dtos.User) (dtos.User, error)
+	IsFriend(context.Context, string, string) (bool, error)
+	PostFriendRequest(context.Context, dtos.FriendRequest) (dtos.FriendRequest, error)
+	GetFriendRequest(context.Context, string) (dtos.FriendRequest, error)
+	GetFriendRequests(context.Context, string, string) ([]dtos.FriendRequest, error)
+	AcceptFriendRequest(context.Context, string) error
+	DeleteFriendRequest(context.Context, string) error
+	DeleteFriend(context.Context, string, string) error
+	BlockUser(context.Context, string, dtos.BlockedUser) error
+	UnblockUser(context.Context, string, string) error
+	GetBlockedUsers(context.Context, string) ([]dtos.BlockedUser, error)
+	IsBlocked(context.Context, string, string) (bool, error)
+	DeleteAccessToken(context.Context, string) error
+	AccessTokenExists(context.Context, string) error
+	GetAccessToken(context.Context, string) (TokenInfo, error)
+	AddAccessToken(context.Context, string, dtos.User, string) error
+	AddRefreshToken(context.Context, string, RefreshToken) error
+	UseRefreshToken(context.Context, string) (RefreshToken, []string, error)
+	RevokeRefreshTokens(context.Context, string) error
+	SaveMessage(context.Context, dtos.Message) error
+	GetMessages(context.Context, string, string, int) ([]dtos.Message, error)
+	GetMessage(context.Context, string, string) (dtos.Message, error)
+	UpdateMessageStatus(context.Context, string, string, string) (bool, error)
+	QueueMessage(context.Context, string, dtos.Message) error
+	GetQueuedMessages(context.Context, string) ([]dtos.Message, error)
+	DeleteQueuedMessage(context.Context, string, string) error
+	SavePresence(context.Context, dtos.Presence) error
+	GetPresence(context.Context, string) (dtos.Presence, error)
+	CreateGroup(context.Context, dtos.Group) (dtos.Group, error)
+	GetGroup(context.Context, string) (dtos.Group, error)
+	GetGroups(context.Context, string) ([]dtos.Group, error)
+	RenameGroup(context.Context, string, string) error
+	SetGroupMember(context.Context, string, string, string) error
+	RemoveGroupMember(context.Context, string, string) error
+	DeleteGroup(context.Context, string) error
 }
 
 // Broker manages the internal state of the Storefront service.
@@ -28,18 +59,36 @@ type Broker struct {
 	cfg Config // the storefront's configuration
 }
 
-// New initializes a new Storefront service.
-func New(cfg Config) (*Broker, error) {
-	r := &Broker{}
-
+// New initializes a new Storefront service, backed by the configured backend.
+func New(cfg Config) (Storefront, error) {
 	if err := validateConfig(cfg); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
-	err := initializeFirebase(r)
-	if err != nil {
-		return nil, err
+	if cfg.TokenCacheTTL == 0 {
+		cfg.TokenCacheTTL = defaultTokenCacheTTL
+	}
+
+	var r Storefront
+	switch cfg.Backend {
+	case BackendMemory:
+		r = NewMemory()
+	case BackendSQLite, BackendPostgres:
+		store, err := NewSQL(cfg.Backend, cfg.DSN)
+		if err != nil {
+			return nil, err
+		}
+		r = store
+	default:
+		bkr := &Broker{cfg: cfg}
+
+		err := initializeFirebase(bkr)
+		if err != nil {
+			return nil, err
+		}
+		r = bkr
 	}
 
-	return r, nil
+	// Access tokens are checked on every authenticated request
+	return newTokenCache(r, cfg.TokenCacheTTL), nil
 }
@@ -0,0 +1,679 @@
+// Package storefronttest is a conformance suite for Storefront implementations.
+// Every backend must pass it, so that swapping backends can't silently change
+// the behavior the handlers rely on, errors included.
+package storefronttest
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/anthonydip/flutter-messenger-go/internal/storefront"
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+
+	"github.com/google/uuid"
+)
+
+// Run the conformance suite against a storefront. The suite only creates
+// data under fresh ids, so the storefront does not need to be empty and can
+// be shared between runs.
+func Run(t *testing.T, store storefront.Storefront) {
+	tests := map[string]func(t *testing.T, store storefront.Storefront){
+		"users":           testUsers,
+		"sign in":         testSignIn,
+		"access tokens":   testAccessTokens,
+		"refresh tokens":  testRefreshTokens,
+		"friend requests": testFriendRequests,
+		"friends":         testFriends,
+		"blocked users":   testBlockedUsers,
+		"messages":        testMessages,
+		"message status":  testMessageStatus,
+		"queue":           testQueue,
+		"presence":        testPresence,
+		"groups":          testGroups,
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			test(t, store)
+		})
+	}
+}
+
+func testUsers(t *testing.T, store storefront.Storefront) {
+	ctx := context.Background()
+
+	email := newEmail()
+
+	user, err := store.PostUser(ctx, dtos.User{Email: email, Provider: "Google"})
+	if err != nil {
+		t.Fatalf("couldn't create user: %s", err.Error())
+	}
+
+	if user.Id == "" || user.Email != email || user.Provider != "Google" {
+		t.Fatalf("expected the created user to have an id, its email and provider but got %+v", user)
+	}
+
+	_, err = store.PostUser(ctx, dtos.User{Email: email, Provider: "Facebook"})
+	expectError(t, err, storefront.ErrEmailTaken)
+
+	// Errors also match their kind
+	if !errors.Is(err, storefront.ErrConflict) {
+		t.Fatalf("expected a duplicate email to be a conflict but got %v", err)
+	}
+
+	found, err := store.GetUser(ctx, user.Id)
+	if err != nil || found != user {
+		t.Fatalf("expected to find %+v by id but got %+v (%v)", user, found, err)
+	}
+
+	found, err = store.GetUserByEmail(ctx, email)
+	if err != nil || found != user {
+		t.Fatalf("expected to find %+v by email but got %+v (%v)", user, found, err)
+	}
+
+	_, err = store.GetUser(ctx, uuid.New().String())
+	expectError(t, err, storefront.ErrUserNotFound)
+
+	_, err = store.GetUserByEmail(ctx, newEmail())
+	expectError(t, err, storefront.ErrUserNotFound)
+}
+
+func testSignIn(t *testing.T, store storefront.Storefront) {
+	ctx := context.Background()
+
+	email := newEmail()
+
+	user, err := store.PostUser(ctx, dtos.User{Email: email, Provider: "Flutter", Password: "correct-horse-battery"})
+	if err != nil {
+		t.Fatalf("couldn't create user: %s", err.Error())
+	}
+
+	// The password hash is stored but never handed out
+	found, err := store.GetUserByEmail(ctx, email)
+	if err != nil || found.Id != user.Id || found.Password != "" {
+		t.Fatalf("expected to find the user without their password but got %+v (%v)", found, err)
+	}
+
+	err = store.SignIn(ctx, dtos.User{Email: email, Password: "correct-horse-battery"})
+	if err != nil {
+		t.Fatalf("expected the user to sign in but got %s", err.Error())
+	}
+
+	err = store.SignIn(ctx, dtos.User{Email: email, Password: "wrong-horse-battery"})
+	expectError(t, err, storefront.ErrInvalidPassword)
+
+	err = store.SignIn(ctx, dtos.User{Email: newEmail(), Password: "correct-horse-battery"})
+	expectError(t, err, storefront.ErrUserNotFound)
+}
+
+func testAccessTokens(t *testing.T, store storefront.Storefront) {
+	ctx := context.Background()
+
+	user := newUser(t, store)
+	token := uuid.New().String()
+
+	family := uuid.New().String()
+
+	expectError(t, store.AccessTokenExists(ctx, token), storefront.ErrTokenNotFound)
+
+	_, err := store.GetAccessToken(ctx, token)
+	expectError(t, err, storefront.ErrTokenNotFound)
+
+	if err := store.AddAccessToken(ctx, token, user, family); err != nil {
+		t.Fatalf("couldn't add token: %s", err.Error())
+	}
+
+	if err := store.AccessTokenExists(ctx, token); err != nil {
+		t.Fatalf("expected the token to exist but got %s", err.Error())
+	}
+
+	// The token is stored with the refresh token family it was issued with
+	info, err := store.GetAccessToken(ctx, token)
+	if err != nil {
+		t.Fatalf("couldn't get token: %s", err.Error())
+	}
+
+	expected := storefront.TokenInfo{TokenType: "user", Id: user.Id, Email: user.Email, Provider: user.Provider, FamilyId: family}
+	if info != expected {
+		t.Fatalf("expected token %+v but got %+v", expected, info)
+	}
+
+	if err := store.DeleteAccessToken(ctx, token); err != nil {
+		t.Fatalf("couldn't delete token: %s", err.Error())
+	}
+
+	expectError(t, store.AccessTokenExists(ctx, token), storefront.ErrTokenNotFound)
+
+	// Deleting a missing token is not an error
+	if err := store.DeleteAccessToken(ctx, token); err != nil {
+		t.Fatalf("expected deleting a missing token to succeed but got %s", err.Error())
+	}
+}
+
+func testRefreshTokens(t *testing.T, store storefront.Storefront) {
+	ctx := context.Background()
+
+	user := newUser(t, store)
+	family := uuid.New().String()
+	expiresAt := time.Now().Add(time.Hour).Unix()
+
+	addRefreshToken := func(familyID string, expiresAt int64) string {
+		token := uuid.New().String()
+
+		info := storefront.RefreshToken{FamilyId: familyID, UserId: user.Id, Email: user.Email, Provider: user.Provider, ExpiresAt: expiresAt}
+		if err := store.AddRefreshToken(ctx, token, info); err != nil {
+			t.Fatalf("couldn't add refresh token: %s", err.Error())
+		}
+
+		return token
+	}
+
+	_, _, err := store.UseRefreshToken(ctx, uuid.New().String())
+	expectError(t, err, storefront.ErrTokenNotFound)
+
+	first := addRefreshToken(family, expiresAt)
+
+	info, _, err := store.UseRefreshToken(ctx, first)
+	if err != nil {
+		t.Fatalf("couldn't use refresh token: %s", err.Error())
+	}
+
+	expected := storefront.RefreshToken{FamilyId: family, UserId: user.Id, Email: user.Email, Provider: user.Provider, ExpiresAt: expiresAt, Used: true}
+	if info != expected {
+		t.Fatalf("expected refresh token %+v but got %+v", expected, info)
+	}
+
+	// Rotating issues the next token of the family, along with an access token
+	second := addRefreshToken(family, expiresAt)
+
+	otherFamily := uuid.New().String()
+	accessToken := uuid.New().String()
+	otherAccessToken := uuid.New().String()
+	for token, familyID := range map[string]string{accessToken: family, otherAccessToken: otherFamily} {
+		if err := store.AddAccessToken(ctx, token, user, familyID); err != nil {
+			t.Fatalf("couldn't add access token: %s", err.Error())
+		}
+		if err := store.AccessTokenExists(ctx, token); err != nil {
+			t.Fatalf("expected the access token to exist but got %s", err.Error())
+		}
+	}
+
+	// Replaying the used token revokes the whole family, its successor and
+	// access tokens included
+	_, tokenIDs, err := store.UseRefreshToken(ctx, first)
+	expectError(t, err, storefront.ErrRefreshTokenReused)
+
+	if len(tokenIDs) != 1 || tokenIDs[0] != accessToken {
+		t.Fatalf("expected the access token %s to be deleted but got %v", accessToken, tokenIDs)
+	}
+
+	expectError(t, store.AccessTokenExists(ctx, accessToken), storefront.ErrTokenNotFound)
+
+	_, _, err = store.UseRefreshToken(ctx, second)
+	expectError(t, err, storefront.ErrRefreshTokenRevoked)
+
+	// Other families are left alone
+	if err := store.AccessTokenExists(ctx, otherAccessToken); err != nil {
+		t.Fatalf("expected another family's access token to exist but got %s", err.Error())
+	}
+
+	other := addRefreshToken(otherFamily, expiresAt)
+	if _, _, err := store.UseRefreshToken(ctx, other); err != nil {
+		t.Fatalf("expected another family's token to be usable but got %s", err.Error())
+	}
+
+	expired := addRefreshToken(uuid.New().String(), time.Now().Add(-time.Minute).Unix())
+	_, _, err = store.UseRefreshToken(ctx, expired)
+	expectError(t, err, storefront.ErrRefreshTokenExpired)
+
+	// Revoking a family, e.g. when the user signs out
+	revokedFamily := uuid.New().String()
+	revoked := addRefreshToken(revokedFamily, expiresAt)
+
+	if err := store.RevokeRefreshTokens(ctx, revokedFamily); err != nil {
+		t.Fatalf("couldn't revoke refresh tokens: %s", err.Error())
+	}
+
+	_, _, err = store.UseRefreshToken(ctx, revoked)
+	expectError(t, err, storefront.ErrRefreshTokenRevoked)
+}
+
+func testFriendRequests(t *testing.T, store storefront.Storefront) {
+	ctx := context.Background()
+
+	alice := newUser(t, store)
+	bob := newUser(t, store)
+	carol := newUser(t, store)
+
+	request, err := store.PostFriendRequest(ctx, dtos.FriendRequest{From: alice.Id, To: bob.Id, Timestamp: 1})
+	if err != nil {
+		t.Fatalf("couldn't send friend request: %s", err.Error())
+	}
+
+	expected := dtos.FriendRequest{Id: storefront.FriendRequestID(alice.Id, bob.Id), From: alice.Id, To: bob.Id, Timestamp: 1}
+	if request != expected {
+		t.Fatalf("expected the request %+v but got %+v", expected, request)
+	}
+
+	_, err = store.PostFriendRequest(ctx, dtos.FriendRequest{From: alice.Id, To: bob.Id, Timestamp: 2})
+	expectError(t, err, storefront.ErrFriendRequestSent)
+
+	_, err = store.PostFriendRequest(ctx, dtos.FriendRequest{From: bob.Id, To: alice.Id, Timestamp: 2})
+	expectError(t, err, storefront.ErrFriendRequestReceived)
+
+	if _, err := store.PostFriendRequest(ctx, dtos.FriendRequest{From: carol.Id, To: bob.Id, Timestamp: 3}); err != nil {
+		t.Fatalf("couldn't send friend request: %s", err.Error())
+	}
+
+	found, err := store.GetFriendRequest(ctx, request.Id)
+	if err != nil || found != request {
+		t.Fatalf("expected to find %+v but got %+v (%v)", request, found, err)
+	}
+
+	// Requests are listed oldest first
+	incoming, err := store.GetFriendRequests(ctx, bob.Id, dtos.FriendRequestIncoming)
+	if err != nil || len(incoming) != 2 || incoming[0].From != alice.Id || incoming[1].From != carol.Id {
+		t.Fatalf("expected bob to have the requests of alice and carol but got %+v (%v)", incoming, err)
+	}
+
+	outgoing, err := store.GetFriendRequests(ctx, alice.Id, dtos.FriendRequestOutgoing)
+	if err != nil || len(outgoing) != 1 || outgoing[0] != request {
+		t.Fatalf("expected alice to have sent %+v but got %+v (%v)", request, outgoing, err)
+	}
+
+	_, err = store.GetFriendRequests(ctx, alice.Id, "sideways")
+	expectError(t, err, storefront.ErrInvalidDirection)
+
+	if err := store.AcceptFriendRequest(ctx, request.Id); err != nil {
+		t.Fatalf("couldn't accept friend request: %s", err.Error())
+	}
+
+	_, err = store.GetFriendRequest(ctx, request.Id)
+	expectError(t, err, storefront.ErrFriendRequestNotFound)
+
+	expectFriends(t, store, alice.Id, dtos.Friend{Id: bob.Id, Email: bob.Email})
+	expectFriends(t, store, bob.Id, dtos.Friend{Id: alice.Id, Email: alice.Email})
+
+	_, err = store.PostFriendRequest(ctx, dtos.FriendRequest{From: alice.Id, To: bob.Id, Timestamp: 4})
+	expectError(t, err, storefront.ErrFriendAlreadyAdded)
+
+	// Declining or cancelling removes the request without adding friends
+	if err := store.DeleteFriendRequest(ctx, storefront.FriendRequestID(carol.Id, bob.Id)); err != nil {
+		t.Fatalf("couldn't delete friend request: %s", err.Error())
+	}
+
+	expectFriends(t, store, carol.Id)
+
+	expectError(t, store.DeleteFriendRequest(ctx, storefront.FriendRequestID(carol.Id, bob.Id)), storefront.ErrFriendRequestNotFound)
+	expectError(t, store.AcceptFriendRequest(ctx, storefront.FriendRequestID(carol.Id, bob.Id)), storefront.ErrFriendRequestNotFound)
+
+	// Requests can't be accepted once one of the users is gone
+	ghost := dtos.FriendRequest{From: uuid.New().String(), To: carol.Id, Timestamp: 5}
+	if _, err := store.PostFriendRequest(ctx, ghost); err != nil {
+		t.Fatalf("couldn't send friend request: %s", err.Error())
+	}
+
+	expectError(t, store.AcceptFriendRequest(ctx, storefront.FriendRequestID(ghost.From, ghost.To)), storefront.ErrUserNotFound)
+}
+
+func testFriends(t *testing.T, store storefront.Storefront) {
+	ctx := context.Background()
+
+	alice := newUser(t, store)
+	bob := newUser(t, store)
+	makeFriends(t, store, alice, bob)
+
+	friends, err := store.IsFriend(ctx, alice.Id, bob.Id)
+	if err != nil || !friends {
+		t.Fatalf("expected alice to have bob as a friend (%v)", err)
+	}
+
+	friends, err = store.IsFriend(ctx, alice.Id, uuid.New().String())
+	if err != nil || friends {
+		t.Fatalf("expected alice not to have an unknown user as a friend (%v)", err)
+	}
+
+	// Removing a friend removes the friendship on both sides
+	if err := store.DeleteFriend(ctx, bob.Id, alice.Id); err != nil {
+		t.Fatalf("couldn't delete friend: %s", err.Error())
+	}
+
+	expectFriends(t, store, alice.Id)
+	expectFriends(t, store, bob.Id)
+
+	expectError(t, store.DeleteFriend(ctx, bob.Id, alice.Id), storefront.ErrFriendNotFound)
+}
+
+func testBlockedUsers(t *testing.T, store storefront.Storefront) {
+	ctx := context.Background()
+
+	alice := newUser(t, store)
+	bob := newUser(t, store)
+	carol := newUser(t, store)
+	makeFriends(t, store, alice, bob)
+
+	if _, err := store.PostFriendRequest(ctx, dtos.FriendRequest{From: carol.Id, To: alice.Id, Timestamp: 1}); err != nil {
+		t.Fatalf("couldn't send friend request: %s", err.Error())
+	}
+
+	for _, blocked := range []dtos.BlockedUser{{Id: bob.Id, Timestamp: 2}, {Id: carol.Id, Timestamp: 3}} {
+		if err := store.BlockUser(ctx, alice.Id, blocked); err != nil {
+			t.Fatalf("couldn't block user: %s", err.Error())
+		}
+	}
+
+	// Blocking removes friendships and pending requests in both directions
+	expectFriends(t, store, alice.Id)
+	expectFriends(t, store, bob.Id)
+
+	_, err := store.GetFriendRequest(ctx, storefront.FriendRequestID(carol.Id, alice.Id))
+	expectError(t, err, storefront.ErrFriendRequestNotFound)
+
+	blocked, err := store.GetBlockedUsers(ctx, alice.Id)
+	sort.Slice(blocked, func(i, j int) bool { return blocked[i].Timestamp < blocked[j].Timestamp })
+	if err != nil || len(blocked) != 2 || blocked[0] != (dtos.BlockedUser{Id: bob.Id, Timestamp: 2}) || blocked[1] != (dtos.BlockedUser{Id: carol.Id, Timestamp: 3}) {
+		t.Fatalf("expected alice to have blocked bob and carol but got %+v (%v)", blocked, err)
+	}
+
+	// Blocking only goes one way
+	for _, check := range []struct {
+		user, blocked string
+		expected      bool
+	}{
+		{alice.Id, bob.Id, true},
+		{bob.Id, alice.Id, false},
+	} {
+		isBlocked, err := store.IsBlocked(ctx, check.user, check.blocked)
+		if err != nil || isBlocked != check.expected {
+			t.Fatalf("expected IsBlocked(%s, %s) to be %t but got %t (%v)", check.user, check.blocked, check.expected, isBlocked, err)
+		}
+	}
+
+	if err := store.UnblockUser(ctx, alice.Id, bob.Id); err != nil {
+		t.Fatalf("couldn't unblock user: %s", err.Error())
+	}
+
+	if isBlocked, _ := store.IsBlocked(ctx, alice.Id, bob.Id); isBlocked {
+		t.Fatalf("expected bob to be unblocked")
+	}
+
+	expectError(t, store.UnblockUser(ctx, alice.Id, bob.Id), storefront.ErrUserNotBlocked)
+}
+
+func testMessages(t *testing.T, store storefront.Storefront) {
+	ctx := context.Background()
+
+	conversationID := storefront.ConversationID(uuid.New().String(), uuid.New().String())
+
+	for i, id := range []string{"m-1", "m-2", "m-3"} {
+		message := dtos.Message{Id: id, ConversationId: conversationID, From: "alice", To: "bob", Body: "Hello " + id, Timestamp: int64(i + 1), ServerTimestamp: int64(i + 10), Status: dtos.MessageSent}
+		if err := store.SaveMessage(ctx, message); err != nil {
+			t.Fatalf("couldn't save message: %s", err.Error())
+		}
+	}
+
+	message, err := store.GetMessage(ctx, conversationID, "m-2")
+	expected := dtos.Message{Id: "m-2", ConversationId: conversationID, From: "alice", To: "bob", Body: "Hello m-2", Timestamp: 2, ServerTimestamp: 11, Status: dtos.MessageSent}
+	if err != nil || message != expected {
+		t.Fatalf("expected to get %+v but got %+v (%v)", expected, message, err)
+	}
+
+	_, err = store.GetMessage(ctx, conversationID, "m-9")
+	expectError(t, err, storefront.ErrMessageNotFound)
+
+	// Messages are listed newest first, paging backwards from a cursor
+	page, err := store.GetMessages(ctx, conversationID, "", 2)
+	if err != nil || len(page) != 2 || page[0].Id != "m-3" || page[1].Id != "m-2" {
+		t.Fatalf("expected the two newest messages but got %+v (%v)", page, err)
+	}
+
+	page, err = store.GetMessages(ctx, conversationID, "m-2", 2)
+	if err != nil || len(page) != 1 || page[0].Id != "m-1" {
+		t.Fatalf("expected the oldest message after the cursor but got %+v (%v)", page, err)
+	}
+
+	_, err = store.GetMessages(ctx, conversationID, "m-9", 2)
+	expectError(t, err, storefront.ErrMessageNotFound)
+
+	page, err = store.GetMessages(ctx, storefront.ConversationID(uuid.New().String(), uuid.New().String()), "", 2)
+	if err != nil || len(page) != 0 {
+		t.Fatalf("expected an empty conversation but got %+v (%v)", page, err)
+	}
+}
+
+func testMessageStatus(t *testing.T, store storefront.Storefront) {
+	ctx := context.Background()
+
+	conversationID := storefront.ConversationID(uuid.New().String(), uuid.New().String())
+
+	err := store.SaveMessage(ctx, dtos.Message{Id: "m-1", ConversationId: conversationID, From: "alice", To: "bob", Body: "Hello", ServerTimestamp: 1, Status: dtos.MessageSent})
+	if err != nil {
+		t.Fatalf("couldn't save message: %s", err.Error())
+	}
+
+	// A status only ever moves forward
+	for _, step := range []struct {
+		status  string
+		updated bool
+	}{
+		{dtos.MessageDelivered, true},
+		{dtos.MessageDelivered, false},
+		{dtos.MessageRead, true},
+		{dtos.MessageDelivered, false},
+	} {
+		updated, err := store.UpdateMessageStatus(ctx, conversationID, "m-1", step.status)
+		if err != nil || updated != step.updated {
+			t.Fatalf("expected updating the status to %s to report %t but got %t (%v)", step.status, step.updated, updated, err)
+		}
+	}
+
+	if message, _ := store.GetMessage(ctx, conversationID, "m-1"); message.Status != dtos.MessageRead {
+		t.Fatalf("expected the message to be read but got %s", message.Status)
+	}
+
+	_, err = store.UpdateMessageStatus(ctx, conversationID, "m-1", "lost")
+	expectError(t, err, storefront.ErrInvalidStatus)
+
+	_, err = store.UpdateMessageStatus(ctx, conversationID, "m-9", dtos.MessageRead)
+	expectError(t, err, storefront.ErrMessageNotFound)
+}
+
+func testQueue(t *testing.T, store storefront.Storefront) {
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+
+	for _, message := range []dtos.Message{
+		{Id: "m-2", ServerTimestamp: 2},
+		{Id: "m-1", ServerTimestamp: 1},
+		{Id: "m-3", ServerTimestamp: 3},
+	} {
+		message.ConversationId = "c"
+		message.From = "alice"
+		message.To = userID
+		message.Body = "Hello"
+		message.Status = dtos.MessageSent
+
+		if err := store.QueueMessage(ctx, userID, message); err != nil {
+			t.Fatalf("couldn't queue message: %s", err.Error())
+		}
+	}
+
+	// Queued messages are delivered oldest first
+	queued, err := store.GetQueuedMessages(ctx, userID)
+	if err != nil || len(queued) != 3 || queued[0].Id != "m-1" || queued[1].Id != "m-2" || queued[2].Id != "m-3" {
+		t.Fatalf("expected the queued messages oldest first but got %+v (%v)", queued, err)
+	}
+
+	if err := store.DeleteQueuedMessage(ctx, userID, "m-2"); err != nil {
+		t.Fatalf("couldn't delete queued message: %s", err.Error())
+	}
+
+	queued, err = store.GetQueuedMessages(ctx, userID)
+	if err != nil || len(queued) != 2 || queued[0].Id != "m-1" || queued[1].Id != "m-3" {
+		t.Fatalf("expected the acknowledged message to be removed but got %+v (%v)", queued, err)
+	}
+
+	queued, err = store.GetQueuedMessages(ctx, uuid.New().String())
+	if err != nil || len(queued) != 0 {
+		t.Fatalf("expected an empty queue but got %+v (%v)", queued, err)
+	}
+}
+
+func testPresence(t *testing.T, store storefront.Storefront) {
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+
+	// Users never seen are offline
+	presence, err := store.GetPresence(ctx, userID)
+	expected := dtos.Presence{Id: userID, Status: dtos.PresenceOffline}
+	if err != nil || presence != expected {
+		t.Fatalf("expected %+v but got %+v (%v)", expected, presence, err)
+	}
+
+	for _, expected := range []dtos.Presence{
+		{Id: userID, Status: dtos.PresenceOnline},
+		{Id: userID, Status: dtos.PresenceOffline, LastSeen: 42},
+	} {
+		if err := store.SavePresence(ctx, expected); err != nil {
+			t.Fatalf("couldn't save presence: %s", err.Error())
+		}
+
+		presence, err := store.GetPresence(ctx, userID)
+		if err != nil || presence != expected {
+			t.Fatalf("expected %+v but got %+v (%v)", expected, presence, err)
+		}
+	}
+}
+
+func testGroups(t *testing.T, store storefront.Storefront) {
+	ctx := context.Background()
+
+	alice := uuid.New().String()
+	bob := uuid.New().String()
+	carol := uuid.New().String()
+
+	group, err := store.CreateGroup(ctx, dtos.Group{Name: "Weekend Plans", Members: map[string]string{alice: dtos.GroupAdmin, bob: dtos.GroupMember}})
+	if err != nil {
+		t.Fatalf("couldn't create group: %s", err.Error())
+	}
+
+	if group.Id == "" || group.Name != "Weekend Plans" || len(group.Members) != 2 {
+		t.Fatalf("expected the created group to have an id, its name and members but got %+v", group)
+	}
+
+	if err := store.RenameGroup(ctx, group.Id, "Weekday Plans"); err != nil {
+		t.Fatalf("couldn't rename group: %s", err.Error())
+	}
+
+	if err := store.SetGroupMember(ctx, group.Id, carol, dtos.GroupMember); err != nil {
+		t.Fatalf("couldn't add member: %s", err.Error())
+	}
+
+	if err := store.SetGroupMember(ctx, group.Id, bob, dtos.GroupAdmin); err != nil {
+		t.Fatalf("couldn't change role: %s", err.Error())
+	}
+
+	if err := store.RemoveGroupMember(ctx, group.Id, alice); err != nil {
+		t.Fatalf("couldn't remove member: %s", err.Error())
+	}
+
+	found, err := store.GetGroup(ctx, group.Id)
+	if err != nil || found.Name != "Weekday Plans" || len(found.Members) != 2 || found.Members[bob] != dtos.GroupAdmin || found.Members[carol] != dtos.GroupMember {
+		t.Fatalf("expected bob to admin the renamed group with carol but got %+v (%v)", found, err)
+	}
+
+	groups, err := store.GetGroups(ctx, carol)
+	if err != nil || len(groups) != 1 || groups[0].Id != group.Id {
+		t.Fatalf("expected carol to be in the group but got %+v (%v)", groups, err)
+	}
+
+	groups, err = store.GetGroups(ctx, alice)
+	if err != nil || len(groups) != 0 {
+		t.Fatalf("expected alice to be in no group but got %+v (%v)", groups, err)
+	}
+
+	if err := store.DeleteGroup(ctx, group.Id); err != nil {
+		t.Fatalf("couldn't delete group: %s", err.Error())
+	}
+
+	_, err = store.GetGroup(ctx, group.Id)
+	expectError(t, err, storefront.ErrGroupNotFound)
+
+	expectError(t, store.RenameGroup(ctx, group.Id, "Nope"), storefront.ErrGroupNotFound)
+	expectError(t, store.SetGroupMember(ctx, group.Id, alice, dtos.GroupMember), storefront.ErrGroupNotFound)
+	expectError(t, store.RemoveGroupMember(ctx, group.Id, bob), storefront.ErrGroupNotFound)
+}
+
+// Create a user with a fresh email
+func newUser(t *testing.T, store storefront.Storefront) dtos.User {
+	t.Helper()
+
+	ctx := context.Background()
+
+	user, err := store.PostUser(ctx, dtos.User{Email: newEmail(), Provider: "Google"})
+	if err != nil {
+		t.Fatalf("couldn't create user: %s", err.Error())
+	}
+
+	return user
+}
+
+// Make two users friends through an accepted friend request
+func makeFriends(t *testing.T, store storefront.Storefront, user dtos.User, friend dtos.User) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	request, err := store.PostFriendRequest(ctx, dtos.FriendRequest{From: user.Id, To: friend.Id})
+	if err != nil {
+		t.Fatalf("couldn't send friend request: %s", err.Error())
+	}
+
+	if err := store.AcceptFriendRequest(ctx, request.Id); err != nil {
+		t.Fatalf("couldn't accept friend request: %s", err.Error())
+	}
+}
+
+// Check the friends of a user, in any order
+func expectFriends(t *testing.T, store storefront.Storefront, userID string, expected ...dtos.Friend) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	friends, err := store.GetAllFriends(ctx, userID)
+	if err != nil {
+		t.Fatalf("couldn't get friends: %s", err.Error())
+	}
+
+	sort.Slice(friends, func(i, j int) bool { return friends[i].Id < friends[j].Id })
+	sort.Slice(expected, func(i, j int) bool { return expected[i].Id < expected[j].Id })
+
+	if len(friends) != len(expected) {
+		t.Fatalf("expected the friends %+v but got %+v", expected, friends)
+	}
+
+	for i := range friends {
+		if friends[i] != expected[i] {
+			t.Fatalf("expected the friends %+v but got %+v", expected, friends)
+		}
+	}
+}
+
+// Check that an error is the expected storefront error, and of its kind
+func expectError(t *testing.T, err error, expected *storefront.Error) {
+	t.Helper()
+
+	if !errors.Is(err, expected) {
+		t.Fatalf("expected the error %q but got %v", expected, err)
+	}
+}
+
+func newEmail() string {
+	return uuid.New().String() + "@storefront-test.com"
+}
@@ -2,9 +2,15 @@ package storefront
 
 import (
 	"context"
-	"fmt"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
 
 	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+
+	"cloud.google.com/go/firestore"
+	"github.com/mitchellh/mapstructure"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -14,14 +20,15 @@ type TokenInfo struct {
 	Id        string
 	Email     string
 	Provider  string
+	FamilyId  string // refresh token family issued with the token
 }
 
-// Function to check if an access token exists in the database
-func (bkr Broker) AccessTokenExists(token string) error {
-	_, err := bkr.Firestore.Collection("tokens").Doc(token).Get(context.Background())
+// Function to check if an access token exists in the database, by its ID
+func (bkr Broker) AccessTokenExists(ctx context.Context, tokenID string) error {
+	_, err := bkr.Firestore.Collection("tokens").Doc(tokenID).Get(ctx)
 	if err != nil {
 		if status.Code(err) == codes.NotFound {
-			return fmt.Errorf("token not found")
+			return ErrTokenNotFound
 		}
 		return err
 	}
@@ -29,9 +36,25 @@ func (bkr Broker) AccessTokenExists(token string) error {
 	return nil
 }
 
-// Function to delete the access token from the database
-func (bkr Broker) DeleteAccessToken(token string) error {
-	_, err := bkr.Firestore.Collection("tokens").Doc(token).Delete(context.Background())
+// Function to get the access token with an ID from the database
+func (bkr Broker) GetAccessToken(ctx context.Context, tokenID string) (TokenInfo, error) {
+	dsnap, err := bkr.Firestore.Collection("tokens").Doc(tokenID).Get(ctx)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return TokenInfo{}, ErrTokenNotFound
+		}
+		return TokenInfo{}, err
+	}
+
+	info := TokenInfo{}
+	mapstructure.Decode(dsnap.Data(), &info)
+
+	return info, nil
+}
+
+// Function to delete the access token with an ID from the database, revoking it
+func (bkr Broker) DeleteAccessToken(ctx context.Context, tokenID string) error {
+	_, err := bkr.Firestore.Collection("tokens").Doc(tokenID).Delete(ctx)
 	if err != nil {
 		return err
 	}
@@ -39,19 +62,161 @@ func (bkr Broker) DeleteAccessToken(token string) error {
 	return nil
 }
 
-// Function to add the access token to the database
-func (bkr Broker) AddAccessToken(token string, user dtos.User) error {
+// Function to add the access token with an ID to the database, along with the
+// refresh token family issued with it
+func (bkr Broker) AddAccessToken(ctx context.Context, tokenID string, user dtos.User, familyID string) error {
 	info := TokenInfo{
 		"user",
 		user.Id,
 		user.Email,
 		user.Provider,
+		familyID,
 	}
 
-	_, err := bkr.Firestore.Collection("tokens").Doc(token).Set(context.Background(), info)
+	_, err := bkr.Firestore.Collection("tokens").Doc(tokenID).Set(ctx, info)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// RefreshToken is what is stored of an opaque refresh token. The tokens
+// rotated from the same sign in are a family, revoked as a whole when a used
+// token is presented again, and expiring all at once when the first did.
+type RefreshToken struct {
+	FamilyId  string `firestore:"familyId"`
+	UserId    string `firestore:"userId"`
+	Email     string `firestore:"email"`
+	Provider  string `firestore:"provider"`
+	ExpiresAt int64  `firestore:"expiresAt"` // unix time in seconds
+	Used      bool   `firestore:"used"`
+	Revoked   bool   `firestore:"revoked"`
+}
+
+// Refresh tokens are stored by their hash, so that the database alone can't
+// be used to refresh a session
+func refreshTokenKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Why a stored refresh token can't be exchanged, nil when it can
+func refreshTokenError(info RefreshToken, now time.Time) error {
+	switch {
+	case info.Revoked:
+		return ErrRefreshTokenRevoked
+	case info.Used:
+		return ErrRefreshTokenReused
+	case info.ExpiresAt <= now.Unix():
+		return ErrRefreshTokenExpired
+	}
+
+	return nil
+}
+
+// Function to add a refresh token to the database
+func (bkr Broker) AddRefreshToken(ctx context.Context, token string, info RefreshToken) error {
+	_, err := bkr.Firestore.Collection("refreshTokens").Doc(refreshTokenKey(token)).Set(ctx, info)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Function to exchange a refresh token, marking it used so that it can only
+// be exchanged once. Presenting a used token again revokes its whole family
+// and deletes the access tokens issued with it, whose IDs are returned.
+func (bkr Broker) UseRefreshToken(ctx context.Context, token string) (RefreshToken, []string, error) {
+	tokens := bkr.Firestore.Collection("refreshTokens")
+	ref := tokens.Doc(refreshTokenKey(token))
+
+	info := RefreshToken{}
+	reused := false
+	var tokenIDs []string
+	err := bkr.Firestore.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		tokenIDs = nil
+
+		dsnap, err := tx.Get(ref)
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				return ErrTokenNotFound
+			}
+			return err
+		}
+
+		info = RefreshToken{}
+		mapstructure.Decode(dsnap.Data(), &info)
+
+		err = refreshTokenError(info, time.Now())
+		if errors.Is(err, ErrRefreshTokenReused) {
+			// The revocation must be committed, so the transaction succeeds
+			reused = true
+
+			docs, err := tx.Documents(tokens.Where("familyId", "==", info.FamilyId)).GetAll()
+			if err != nil {
+				return err
+			}
+
+			accessDocs, err := tx.Documents(bkr.Firestore.Collection("tokens").Where("FamilyId", "==", info.FamilyId)).GetAll()
+			if err != nil {
+				return err
+			}
+
+			for _, doc := range docs {
+				err = tx.Update(doc.Ref, []firestore.Update{{Path: "revoked", Value: true}})
+				if err != nil {
+					return err
+				}
+			}
+
+			for _, doc := range accessDocs {
+				err = tx.Delete(doc.Ref)
+				if err != nil {
+					return err
+				}
+				tokenIDs = append(tokenIDs, doc.Ref.ID)
+			}
+
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		return tx.Update(ref, []firestore.Update{{Path: "used", Value: true}})
+	})
+	if err != nil {
+		return RefreshToken{}, nil, err
+	}
+
+	if reused {
+		return RefreshToken{}, tokenIDs, ErrRefreshTokenReused
+	}
+
+	info.Used = true
+	return info, nil, nil
+}
+
+// Function to revoke every refresh token of a family
+func (bkr Broker) RevokeRefreshTokens(ctx context.Context, familyID string) error {
+	docs, err := bkr.Firestore.Collection("refreshTokens").Where("familyId", "==", familyID).Documents(ctx).GetAll()
+	if err != nil {
+		return err
+	}
+
+	batch := bkr.Firestore.Batch()
+	for _, doc := range docs {
+		batch.Update(doc.Ref, []firestore.Update{{Path: "revoked", Value: true}})
+	}
+
+	if len(docs) > 0 {
+		_, err = batch.Commit(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package storefront
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+)
+
+// tokenCache wraps a storefront, caching whether access tokens exist so that
+// authenticating a request doesn't read the database every time. Tokens added
+// or deleted through the cache are known right away, those deleted through
+// another server once their entry expires.
+type tokenCache struct {
+	Storefront
+
+	mu        sync.Mutex
+	ttl       time.Duration
+	tokens    map[string]tokenEntry
+	lastSweep time.Time
+}
+
+// tokenEntry is whether an access token existed as of when it was checked
+type tokenEntry struct {
+	exists  bool
+	expires time.Time
+}
+
+func newTokenCache(store Storefront, ttl time.Duration) *tokenCache {
+	return &tokenCache{
+		Storefront: store,
+		ttl:        ttl,
+		tokens:     make(map[string]tokenEntry),
+		lastSweep:  time.Now(),
+	}
+}
+
+// Check if an access token exists, reading the storefront only if it isn't
+// cached
+func (c *tokenCache) AccessTokenExists(ctx context.Context, tokenID string) error {
+	c.mu.Lock()
+	entry, ok := c.tokens[tokenID]
+	c.mu.Unlock()
+
+	if !ok || !time.Now().Before(entry.expires) {
+		err := c.Storefront.AccessTokenExists(ctx, tokenID)
+		if err != nil && !errors.Is(err, ErrTokenNotFound) {
+			return err
+		}
+
+		entry = c.set(tokenID, err == nil)
+	}
+
+	if !entry.exists {
+		return ErrTokenNotFound
+	}
+
+	return nil
+}
+
+// Delete an access token, revoking it on this server right away
+func (c *tokenCache) DeleteAccessToken(ctx context.Context, tokenID string) error {
+	err := c.Storefront.DeleteAccessToken(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+
+	c.set(tokenID, false)
+
+	return nil
+}
+
+// Add an access token, accepting it on this server right away
+func (c *tokenCache) AddAccessToken(ctx context.Context, tokenID string, user dtos.User, familyID string) error {
+	err := c.Storefront.AddAccessToken(ctx, tokenID, user, familyID)
+	if err != nil {
+		return err
+	}
+
+	c.set(tokenID, true)
+
+	return nil
+}
+
+// Exchange a refresh token, revoking on this server right away the access
+// tokens deleted when the token was reused
+func (c *tokenCache) UseRefreshToken(ctx context.Context, token string) (RefreshToken, []string, error) {
+	info, tokenIDs, err := c.Storefront.UseRefreshToken(ctx, token)
+
+	for _, tokenID := range tokenIDs {
+		c.set(tokenID, false)
+	}
+
+	return info, tokenIDs, err
+}
+
+// Cache whether an access token exists, dropping the expired entries once per
+// TTL so that the cache only holds recently used tokens
+func (c *tokenCache) set(tokenID string, exists bool) tokenEntry {
+	now := time.Now()
+	entry := tokenEntry{exists: exists, expires: now.Add(c.ttl)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.Sub(c.lastSweep) >= c.ttl {
+		for id, e := range c.tokens {
+			if !now.Before(e.expires) {
+				delete(c.tokens, id)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	c.tokens[tokenID] = entry
+
+	return entry
+}
@@ -0,0 +1,62 @@
+package storefront
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+)
+
+func TestTokenCache(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ttl := 50 * time.Millisecond
+
+	// Two servers sharing a database
+	store := NewMemory()
+	local := newTokenCache(store, ttl)
+	remote := newTokenCache(store, ttl)
+
+	err := local.AddAccessToken(ctx, "token-id", dtos.User{Id: "user-id"}, "family-id")
+	if err != nil {
+		t.Fatalf("couldn't add token: %s", err.Error())
+	}
+
+	if err := remote.AccessTokenExists(ctx, "token-id"); err != nil {
+		t.Fatalf("expected the token to exist but got %v", err)
+	}
+
+	err = local.DeleteAccessToken(ctx, "token-id")
+	if err != nil {
+		t.Fatalf("couldn't delete token: %s", err.Error())
+	}
+
+	// The server the token was deleted through rejects it right away
+	if err := local.AccessTokenExists(ctx, "token-id"); !errors.Is(err, ErrTokenNotFound) {
+		t.Fatalf("expected %v but got %v", ErrTokenNotFound, err)
+	}
+
+	// The other server accepts it until its cache expires
+	if err := remote.AccessTokenExists(ctx, "token-id"); err != nil {
+		t.Fatalf("expected the cached token to exist but got %v", err)
+	}
+
+	time.Sleep(ttl)
+
+	if err := remote.AccessTokenExists(ctx, "token-id"); !errors.Is(err, ErrTokenNotFound) {
+		t.Fatalf("expected %v after the cache expired but got %v", ErrTokenNotFound, err)
+	}
+
+	// Expired entries are dropped once the cache is written to again
+	time.Sleep(ttl)
+	remote.AccessTokenExists(ctx, "other-token-id")
+
+	remote.mu.Lock()
+	defer remote.mu.Unlock()
+	if _, ok := remote.tokens["token-id"]; ok {
+		t.Fatal("expected the expired entry to be dropped")
+	}
+}
@@ -2,7 +2,6 @@ package storefront
 
 import (
 	"context"
-	"fmt"
 	"strconv"
 
 	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
@@ -15,13 +14,13 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-func (bkr Broker) GetUser(id string) (dtos.User, error) {
+func (bkr Broker) GetUser(ctx context.Context, id string) (dtos.User, error) {
 	user := dtos.User{}
 
-	dsnap, err := bkr.Firestore.Collection("users").Doc(id).Get(context.Background())
+	dsnap, err := bkr.Firestore.Collection("users").Doc(id).Get(ctx)
 	if err != nil {
 		if status.Code(err) == codes.NotFound {
-			return dtos.User{}, fmt.Errorf("user not found")
+			return dtos.User{}, ErrUserNotFound
 		}
 		return dtos.User{}, err
 	}
@@ -33,10 +32,10 @@ func (bkr Broker) GetUser(id string) (dtos.User, error) {
 	return user, nil
 }
 
-func (bkr Broker) GetUserByEmail(email string) (dtos.User, error) {
+func (bkr Broker) GetUserByEmail(ctx context.Context, email string) (dtos.User, error) {
 	user := dtos.User{}
 
-	iter := bkr.Firestore.Collection("users").Where("email", "==", email).Limit(1).Documents(context.Background())
+	iter := bkr.Firestore.Collection("users").Where("email", "==", email).Limit(1).Documents(ctx)
 	for {
 		doc, err := iter.Next()
 		if err == iterator.Done {
@@ -55,7 +54,7 @@ func (bkr Broker) GetUserByEmail(email string) (dtos.User, error) {
 
 	// User does not exist
 	if (dtos.User{}) == user {
-		return dtos.User{}, fmt.Errorf("user does not exist")
+		return dtos.User{}, ErrUserNotFound
 	}
 
 	user.Password = ""
@@ -63,10 +62,10 @@ func (bkr Broker) GetUserByEmail(email string) (dtos.User, error) {
 	return user, nil
 }
 
-func (bkr Broker) GetAllFriends(id string) ([]dtos.Friend, error) {
+func (bkr Broker) GetAllFriends(ctx context.Context, id string) ([]dtos.Friend, error) {
 	friends := make([]dtos.Friend, 0)
 
-	iter := bkr.Firestore.Collection("users").Doc(id).Collection("friends").Documents(context.Background())
+	iter := bkr.Firestore.Collection("users").Doc(id).Collection("friends").Documents(ctx)
 	for {
 		doc, err := iter.Next()
 		if err == iterator.Done {
@@ -90,11 +89,11 @@ func (bkr Broker) GetAllFriends(id string) ([]dtos.Friend, error) {
 }
 
 // Sign a user in, checking password
-func (bkr Broker) SignIn(userInfo dtos.User) error {
+func (bkr Broker) SignIn(ctx context.Context, userInfo dtos.User) error {
 	user := dtos.User{}
 
 	// Search for user
-	iter := bkr.Firestore.Collection("users").Where("email", "==", userInfo.Email).Limit(1).Documents(context.Background())
+	iter := bkr.Firestore.Collection("users").Where("email", "==", userInfo.Email).Limit(1).Documents(ctx)
 	for {
 		doc, err := iter.Next()
 		if err == iterator.Done {
@@ -113,23 +112,23 @@ func (bkr Broker) SignIn(userInfo dtos.User) error {
 
 	// User does not exist
 	if (dtos.User{}) == user {
-		return fmt.Errorf("user does not exist")
+		return ErrUserNotFound
 	}
 
 	// Validate the request password is the same as the hash password
 	err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(userInfo.Password))
 	if err != nil {
-		return fmt.Errorf("invalid password")
+		return ErrInvalidPassword
 	}
 
 	return nil
 }
 
-func (bkr Broker) PostUser(userInfo dtos.User) (dtos.User, error) {
+func (bkr Broker) PostUser(ctx context.Context, userInfo dtos.User) (dtos.User, error) {
 	user := dtos.User{}
 
 	// Check if the email already exists in firestore
-	iter := bkr.Firestore.Collection("users").Where("email", "==", userInfo.Email).Limit(1).Documents(context.Background())
+	iter := bkr.Firestore.Collection("users").Where("email", "==", userInfo.Email).Limit(1).Documents(ctx)
 	for {
 		_, err := iter.Next()
 		if err == iterator.Done {
@@ -141,7 +140,7 @@ func (bkr Broker) PostUser(userInfo dtos.User) (dtos.User, error) {
 		}
 
 		// Email already exists in the database
-		return dtos.User{}, fmt.Errorf("409 Conflict")
+		return dtos.User{}, ErrEmailTaken
 	}
 
 	if userInfo.Provider == "Flutter" {
@@ -185,7 +184,7 @@ func (bkr Broker) PostUser(userInfo dtos.User) (dtos.User, error) {
 	}
 
 	// Add the new user to the database
-	_, err := bkr.Firestore.Collection("users").Doc(user.Id).Set(context.Background(), user)
+	_, err := bkr.Firestore.Collection("users").Doc(user.Id).Set(ctx, user)
 	// Error creating user in the database
 	if err != nil {
 		return dtos.User{}, err
@@ -194,24 +193,15 @@ func (bkr Broker) PostUser(userInfo dtos.User) (dtos.User, error) {
 	return user, nil
 }
 
-func (bkr Broker) PostFriend(userID string, friend dtos.User) error {
-	// Check if the friend is already added
-	_, err := bkr.Firestore.Collection("users").Doc(userID).Collection("friends").Doc(friend.Id).Get(context.Background())
-	// Error is expected in this case
+// Check if a user has added another user as a friend
+func (bkr Broker) IsFriend(ctx context.Context, userID string, friendID string) (bool, error) {
+	_, err := bkr.Firestore.Collection("users").Doc(userID).Collection("friends").Doc(friendID).Get(ctx)
 	if err != nil {
-		// If friend is not added, add them
 		if status.Code(err) == codes.NotFound {
-			_, err = bkr.Firestore.Collection("users").Doc(userID).Collection("friends").Doc(friend.Id).Set(context.Background(), friend)
-			if err != nil {
-				return err
-			}
-
-			// Friend successfully added
-			return nil
-		} else {
-			return err
+			return false, nil
 		}
+		return false, err
 	}
 
-	return fmt.Errorf("friend already added")
+	return true, nil
 }
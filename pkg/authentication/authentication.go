@@ -1,20 +1,28 @@
 package authentication
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
 	"fmt"
-	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+	"github.com/fsnotify/fsnotify"
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/rs/zerolog/log"
+	"github.com/google/uuid"
 )
 
-const (
-	privAccessKeyPath   = "../../keys/rsa-access-key.private"
-	pubAccessKeyPath    = "../../keys/rsa-access-key.public"
-	privInternalKeyPath = "../../keys/rsa-internal-key.private"
-	pubInternalKeyPath  = "../../keys/rsa-internal-key.public"
+// Errors returned by the authentication agent
+var (
+	ErrInvalidToken    = errors.New("invalid token")
+	ErrReadingKey      = errors.New("error reading pem")
+	ErrParsingKey      = errors.New("error parsing pem")
+	ErrSigningToken    = errors.New("error signing token")
+	ErrGeneratingToken = errors.New("error generating token")
 )
 
 type JwtClaims struct {
@@ -23,139 +31,185 @@ type JwtClaims struct {
 	UserID    string
 	Email     string
 	Provider  string
+	Scopes    []string `json:",omitempty"`
 }
 
 // Auth exposes all functionalities of the Auth agent
 type Authentication interface {
-	GenerateAccessToken(user dtos.User) (string, error)
-	ValidateJWT(token string) bool
-	ValidateParseJWT(token string) (dtos.User, error)
-	ValidateInternalJWT(token string) bool
+	GenerateAccessToken(ctx context.Context, user dtos.User) (string, error)
+	GenerateRefreshToken(ctx context.Context) (string, time.Time, error)
+	TokenID(ctx context.Context, token string) (string, error)
+	ParseJWT(ctx context.Context, token string) (Principal, error)
+	ParseInternalJWT(ctx context.Context, token string) (Principal, error)
+	PublicKeys(ctx context.Context) JWKS
+	Close() error
 }
 
 // Broker manages the internal state of the Auth agent.
-type Broker struct{}
+type Broker struct {
+	cfg     Config                 // the auth agent's configuration
+	keys    atomic.Pointer[keySet] // the keys, swapped when reloaded
+	watcher *fsnotify.Watcher      // the watcher of the key files
+	done    chan struct{}          // closed when the agent is closed
+	closed  sync.Once              // closes the agent only once
+}
 
 // New create a new authorization agent.
 func New(cfg Config) (Authentication, error) {
-	return &Broker{}, nil
-}
+	if err := validateConfig(cfg); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
 
-// Generate a new access token for a user
-func (bkr *Broker) GenerateAccessToken(user dtos.User) (string, error) {
-	// Read the private PEM key for the user access token
-	signBytes, err := os.ReadFile(privAccessKeyPath)
+	if cfg.AccessTokenTTL == 0 {
+		cfg.AccessTokenTTL = defaultAccessTokenTTL
+	}
+	if cfg.RefreshTokenTTL == 0 {
+		cfg.RefreshTokenTTL = defaultRefreshTokenTTL
+	}
+	if cfg.Issuer == "" {
+		cfg.Issuer = defaultIssuer
+	}
+	if cfg.Audience == "" {
+		cfg.Audience = defaultAudience
+	}
+	if cfg.InternalIssuer == "" {
+		cfg.InternalIssuer = defaultInternalIssuer
+	}
+	if len(cfg.Algorithms) == 0 {
+		cfg.Algorithms = defaultAlgorithms
+	}
+	if len(cfg.AccessKeys) == 0 {
+		cfg.AccessKeys = []AccessKey{{
+			ID:             defaultAccessKeyID,
+			PrivateKeyPath: defaultAccessPrivateKeyPath,
+			PublicKeyPath:  defaultAccessPublicKeyPath,
+		}}
+	}
+	if cfg.InternalPublicKeyPath == "" {
+		cfg.InternalPublicKeyPath = defaultInternalPublicKeyPath
+	}
+
+	keys, err := loadKeys(cfg)
 	if err != nil {
-		log.Fatal().Err(err).Str("function", "GenerateAccessToken").Msg("Error reading private PEM key")
-		return "", fmt.Errorf("error reading file")
+		return nil, err
 	}
 
-	// Parse RSA from the private key
-	signKey, err := jwt.ParseRSAPrivateKeyFromPEM(signBytes)
+	r := &Broker{cfg: cfg, done: make(chan struct{})}
+	r.keys.Store(keys)
+
+	err = r.watch()
 	if err != nil {
-		log.Fatal().Err(err).Str("function", "GenerateAccessToken").Msg("Error parsing private PEM key")
-		return "", fmt.Errorf("error parsing pem")
+		return nil, fmt.Errorf("error watching keys: %w", err)
 	}
 
-	// Create claims with a 1 minute expire time
+	return r, nil
+}
+
+// Generate a new access token for a user
+func (bkr *Broker) GenerateAccessToken(ctx context.Context, user dtos.User) (string, error) {
+	// Create claims expiring after the access token TTL, identified by a jti
+	// that the token is stored and revoked by
 	claims := JwtClaims{
 		jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Issuer:    bkr.cfg.Issuer,
+			Audience:  jwt.ClaimStrings{bkr.cfg.Audience},
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(bkr.cfg.AccessTokenTTL)),
 		},
-		"user",
+		TokenTypeUser,
 		user.Id,
 		user.Email,
 		user.Provider,
+		nil,
 	}
 
-	// Create new token with claims and sign
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	ss, err := token.SignedString(signKey)
+	// Create new token with claims and sign with the active key, naming it in
+	// the kid header
+	keys := bkr.keys.Load()
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(bkr.cfg.Algorithms[0]), claims)
+	token.Header["kid"] = keys.signingID
+	ss, err := token.SignedString(keys.signing)
 	if err != nil {
-		log.Fatal().Err(err).Str("function", "GenerateAccessToken").Msg("Error signing token")
-		return "", fmt.Errorf("error signing token")
+		return "", fmt.Errorf("%w: %v", ErrSigningToken, err)
 	}
 
 	return ss, nil
 }
 
-// ValidateJWT validates the user JWT token
-func (bkr *Broker) ValidateJWT(tokenString string) bool {
-	// Read the public PEM key for the user access token
-	verifyBytes, err := os.ReadFile(pubAccessKeyPath)
+// Generate a new opaque refresh token and the time it expires at
+func (bkr *Broker) GenerateRefreshToken(ctx context.Context) (string, time.Time, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
 	if err != nil {
-		log.Fatal().Err(err).Str("function", "ValidateJWT").Msg("Error reading public PEM key")
-		return false
+		return "", time.Time{}, ErrGeneratingToken
 	}
 
-	// Parse RSA from the public key
-	verifyKey, err := jwt.ParseRSAPublicKeyFromPEM(verifyBytes)
-	if err != nil {
-		log.Fatal().Err(err).Str("function", "ValidateJWT").Msg("Error parsing public PEM key")
-		return false
-	}
+	return base64.RawURLEncoding.EncodeToString(b), time.Now().Add(bkr.cfg.RefreshTokenTTL), nil
+}
 
-	// Verify the provided token string
-	_, err = jwt.ParseWithClaims(tokenString, &JwtClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return verifyKey, nil
-	})
+// Read the ID (jti) of an access token without verifying it, to store or
+// revoke the token by
+func (bkr *Broker) TokenID(ctx context.Context, tokenString string) (string, error) {
+	claims := &JwtClaims{}
+	_, _, err := jwt.NewParser().ParseUnverified(tokenString, claims)
+	if err != nil || claims.ID == "" {
+		return "", ErrInvalidToken
+	}
 
-	// If the token is missing or invalid, return false
-	return err == nil
+	return claims.ID, nil
 }
 
-// Function to validate user JWT token and return the associated user information
-func (bkr *Broker) ValidateParseJWT(tokenString string) (dtos.User, error) {
-	// Read the public PEM key for the user access token
-	verifyBytes, err := os.ReadFile(pubAccessKeyPath)
-	if err != nil {
-		return dtos.User{}, fmt.Errorf("error reading pem")
-	}
+// Function to validate a user JWT token and return the principal it was issued to
+func (bkr *Broker) ParseJWT(ctx context.Context, tokenString string) (Principal, error) {
+	keys := bkr.keys.Load()
 
-	// Parse RSA from the public key
-	verifyKey, err := jwt.ParseRSAPublicKeyFromPEM(verifyBytes)
-	if err != nil {
-		return dtos.User{}, fmt.Errorf("error parsing pem")
-	}
+	return bkr.parse(tokenString, bkr.cfg.Issuer, TokenTypeUser, func(token *jwt.Token) (interface{}, error) {
+		// Tokens from before keys were named are verified with the active key
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			kid = keys.signingID
+		}
 
-	// Verify the provided token string
-	token, err := jwt.ParseWithClaims(tokenString, &JwtClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return verifyKey, nil
+		key, ok := keys.access[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key %q", kid)
+		}
+
+		return key, nil
 	})
+}
 
-	if claims, ok := token.Claims.(*JwtClaims); ok && token.Valid {
-		return dtos.User{
-			Id:       claims.UserID,
-			Email:    claims.Email,
-			Provider: claims.Provider,
-		}, nil
-	} else {
-		return dtos.User{}, fmt.Errorf("invalid token")
-	}
+// Function to validate an internal JWT token and return the principal it was issued to
+func (bkr *Broker) ParseInternalJWT(ctx context.Context, tokenString string) (Principal, error) {
+	key := bkr.keys.Load().internalPublic
+
+	return bkr.parse(tokenString, bkr.cfg.InternalIssuer, TokenTypeInternal, func(*jwt.Token) (interface{}, error) {
+		return key, nil
+	})
 }
 
-// ValidateInternalJWT validates the internal JWT token
-func (bkr *Broker) ValidateInternalJWT(tokenString string) bool {
-	// Read the public PEM key for the internal access token
-	verifyBytes, err := os.ReadFile(pubInternalKeyPath)
-	if err != nil {
-		log.Fatal().Err(err).Str("function", "ValidateInternalJWT").Msg("Error reading public PEM key")
-		return false
-	}
+// Validate a JWT token with the public key looked up for it and return its
+// principal. The token must be signed with one of the configured algorithms,
+// expire, be for the audience of the agent, and have the issuer and token type
+// of the kind of token expected, so that one kind is never accepted as another.
+func (bkr *Broker) parse(tokenString string, issuer string, tokenType string, keyFunc jwt.Keyfunc) (Principal, error) {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(bkr.cfg.Algorithms),
+		jwt.WithIssuer(issuer),
+		jwt.WithAudience(bkr.cfg.Audience),
+	)
 
-	// Parse RSA from the public key
-	verifyKey, err := jwt.ParseRSAPublicKeyFromPEM(verifyBytes)
+	// Verify the provided token string
+	token, err := parser.ParseWithClaims(tokenString, &JwtClaims{}, keyFunc)
 	if err != nil {
-		log.Fatal().Err(err).Str("function", "ValidateInternalJWT").Msg("Error parsing public PEM key")
-		return false
+		return Principal{}, ErrInvalidToken
 	}
 
-	// Verify the provided token string
-	_, err = jwt.ParseWithClaims(tokenString, &JwtClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return verifyKey, nil
-	})
+	claims, ok := token.Claims.(*JwtClaims)
+	if !ok || !token.Valid || claims.ExpiresAt == nil || claims.TokenType != tokenType {
+		return Principal{}, ErrInvalidToken
+	}
 
-	// If the token is missing or invalid, return false
-	return err == nil
+	return principalFromClaims(claims), nil
 }
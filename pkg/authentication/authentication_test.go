@@ -0,0 +1,180 @@
+package authentication
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Sign claims with a key, like the services issuing tokens would
+func signTestToken(t *testing.T, method jwt.SigningMethod, key any, kid string, claims JwtClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	ss, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("couldn't sign token: %s", err.Error())
+	}
+
+	return ss
+}
+
+func readTestPrivateKey(t *testing.T, path string) any {
+	t.Helper()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("couldn't read key: %s", err.Error())
+	}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(b)
+	if err != nil {
+		t.Fatalf("couldn't parse key: %s", err.Error())
+	}
+
+	return key
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	// Internal tokens are verified with the same key as access tokens, so that
+	// only their claims tell them apart
+	key := newTestKey(t, t.TempDir(), "access-key")
+	bkr, _ := newTestBroker(t, Config{AccessKeys: []AccessKey{key}, InternalPublicKeyPath: key.PublicKeyPath})
+
+	privateKey := readTestPrivateKey(t, key.PrivateKeyPath)
+	publicKey, err := os.ReadFile(key.PublicKeyPath)
+	if err != nil {
+		t.Fatalf("couldn't read key: %s", err.Error())
+	}
+
+	claims := func(issuer string, tokenType string, edit func(c *JwtClaims)) JwtClaims {
+		c := JwtClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    issuer,
+				Audience:  jwt.ClaimStrings{defaultAudience},
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+			TokenType: tokenType,
+			UserID:    "user-id",
+		}
+		if edit != nil {
+			edit(&c)
+		}
+		return c
+	}
+
+	user := claims(defaultIssuer, TokenTypeUser, nil)
+	internal := claims(defaultInternalIssuer, TokenTypeInternal, nil)
+
+	tests := map[string]struct {
+		token    string
+		internal bool
+		valid    bool
+	}{
+		"user": {
+			token: signTestToken(t, jwt.SigningMethodRS256, privateKey, key.ID, user),
+			valid: true,
+		},
+		"user as internal": {
+			token:    signTestToken(t, jwt.SigningMethodRS256, privateKey, key.ID, user),
+			internal: true,
+		},
+		"internal": {
+			token:    signTestToken(t, jwt.SigningMethodRS256, privateKey, "", internal),
+			internal: true,
+			valid:    true,
+		},
+		"internal as user": {
+			token: signTestToken(t, jwt.SigningMethodRS256, privateKey, key.ID, internal),
+		},
+		"user type from internal issuer": {
+			token: signTestToken(t, jwt.SigningMethodRS256, privateKey, key.ID, claims(defaultInternalIssuer, TokenTypeUser, nil)),
+		},
+		"user type as internal": {
+			token:    signTestToken(t, jwt.SigningMethodRS256, privateKey, "", claims(defaultInternalIssuer, TokenTypeUser, nil)),
+			internal: true,
+		},
+		"other issuer": {
+			token: signTestToken(t, jwt.SigningMethodRS256, privateKey, key.ID, claims("someone-else", TokenTypeUser, nil)),
+		},
+		"no audience": {
+			token: signTestToken(t, jwt.SigningMethodRS256, privateKey, key.ID, claims(defaultIssuer, TokenTypeUser, func(c *JwtClaims) { c.Audience = nil })),
+		},
+		"other audience": {
+			token: signTestToken(t, jwt.SigningMethodRS256, privateKey, key.ID, claims(defaultIssuer, TokenTypeUser, func(c *JwtClaims) { c.Audience = jwt.ClaimStrings{"other-api"} })),
+		},
+		"no expiry": {
+			token: signTestToken(t, jwt.SigningMethodRS256, privateKey, key.ID, claims(defaultIssuer, TokenTypeUser, func(c *JwtClaims) { c.ExpiresAt = nil })),
+		},
+		"expired": {
+			token: signTestToken(t, jwt.SigningMethodRS256, privateKey, key.ID, claims(defaultIssuer, TokenTypeUser, func(c *JwtClaims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute)) })),
+		},
+		"algorithm not configured": {
+			token: signTestToken(t, jwt.SigningMethodRS512, privateKey, key.ID, user),
+		},
+		"public key as HMAC secret": {
+			token: signTestToken(t, jwt.SigningMethodHS256, publicKey, key.ID, user),
+		},
+		"unsigned": {
+			token: signTestToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, key.ID, user),
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			parse := bkr.ParseJWT
+			if test.internal {
+				parse = bkr.ParseInternalJWT
+			}
+
+			_, err := parse(context.Background(), test.token)
+			if test.valid && err != nil {
+				t.Fatalf("expected the token to be valid but got %v", err)
+			}
+			if !test.valid && !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("expected %v but got %v", ErrInvalidToken, err)
+			}
+		})
+	}
+}
+
+func TestGenerateAccessToken(t *testing.T) {
+	t.Parallel()
+
+	bkr, _ := newTestBroker(t, Config{Issuer: "https://storefront.example.com", Audience: "messenger"})
+
+	token, err := bkr.GenerateAccessToken(context.Background(), dtos.User{Id: "user-id"})
+	if err != nil {
+		t.Fatalf("couldn't generate token: %s", err.Error())
+	}
+
+	principal, err := bkr.ParseJWT(context.Background(), token)
+	if err != nil {
+		t.Fatalf("expected the token to be valid but got %v", err)
+	}
+	if principal.TokenType != TokenTypeUser || principal.TokenID == "" {
+		t.Fatalf("unexpected principal %+v", principal)
+	}
+
+	claims := &JwtClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, claims); err != nil {
+		t.Fatalf("couldn't decode token: %s", err.Error())
+	}
+	if claims.Issuer != "https://storefront.example.com" || len(claims.Audience) != 1 || claims.Audience[0] != "messenger" {
+		t.Fatalf("expected the configured issuer and audience but got %+v", claims.RegisteredClaims)
+	}
+}
@@ -1,5 +1,136 @@
 package authentication
 
+import (
+	"fmt"
+	"time"
+)
+
+// Lifetimes of tokens when none are configured
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// Keys when none are configured, paths relative to app/storefront-api
+const (
+	defaultAccessKeyID           = "rsa-access-key"
+	defaultAccessPrivateKeyPath  = "../../keys/rsa-access-key.private"
+	defaultAccessPublicKeyPath   = "../../keys/rsa-access-key.public"
+	defaultInternalPublicKeyPath = "../../keys/rsa-internal-key.public"
+)
+
+// Claims of tokens when none are configured
+const (
+	defaultIssuer         = "storefront-api"
+	defaultAudience       = "storefront-api"
+	defaultInternalIssuer = "storefront-internal"
+)
+
+// Signing algorithms of the tokens when none are configured
+var defaultAlgorithms = []string{"RS256"}
+
+// Algorithms the RSA keys can sign and verify tokens with
+var rsaAlgorithms = map[string]bool{
+	"RS256": true,
+	"RS384": true,
+	"RS512": true,
+	"PS256": true,
+	"PS384": true,
+	"PS512": true,
+}
+
+// AccessKey is a key pair of user access tokens, named by the kid header of
+// the tokens it signs
+type AccessKey struct {
+	ID string `json:"id"`
+
+	// Path of the PEM private key, only needed for the active key
+	PrivateKeyPath string `json:"privateKeyPath,omitempty"`
+
+	// Path of the PEM public key, verifying tokens and published in the JWKS
+	PublicKeyPath string `json:"publicKeyPath"`
+}
+
 type Config struct {
 	URL string
+
+	// Keys of user access tokens. To rotate keys, add the new key, then make it
+	// the active key once every service trusts it, and remove the old key once
+	// the tokens it signed expired.
+	AccessKeys []AccessKey
+
+	// ID of the access key signing new tokens, the first key when empty
+	ActiveAccessKeyID string
+
+	// Path of the PEM public key verifying internal tokens
+	InternalPublicKeyPath string
+
+	// Issuer (iss) and audience (aud) of access tokens, which tokens must have
+	// to be accepted
+	Issuer   string
+	Audience string
+
+	// Issuer of internal tokens, their audience being the same as access tokens
+	InternalIssuer string
+
+	// Algorithms tokens can be signed with, the first one signing access
+	// tokens. RS256 only when empty.
+	Algorithms []string
+
+	// How long access tokens are valid for, short as refresh tokens renew them
+	AccessTokenTTL time.Duration
+
+	// How long a refresh token can be exchanged for new tokens
+	RefreshTokenTTL time.Duration
+}
+
+func validateConfig(cfg Config) error {
+	if cfg.AccessTokenTTL < 0 {
+		return fmt.Errorf("negative access token TTL")
+	}
+
+	if cfg.RefreshTokenTTL < 0 {
+		return fmt.Errorf("negative refresh token TTL")
+	}
+
+	for _, alg := range cfg.Algorithms {
+		if !rsaAlgorithms[alg] {
+			return fmt.Errorf("unsupported algorithm %q for RSA keys", alg)
+		}
+	}
+
+	ids := make(map[string]bool, len(cfg.AccessKeys))
+	for _, key := range cfg.AccessKeys {
+		if key.ID == "" {
+			return fmt.Errorf("access key without an ID")
+		}
+		if ids[key.ID] {
+			return fmt.Errorf("duplicate access key %q", key.ID)
+		}
+		if key.PublicKeyPath == "" {
+			return fmt.Errorf("access key %q has no public key", key.ID)
+		}
+		ids[key.ID] = true
+	}
+
+	if cfg.ActiveAccessKeyID != "" && !ids[cfg.ActiveAccessKeyID] {
+		return fmt.Errorf("unknown active access key %q", cfg.ActiveAccessKeyID)
+	}
+
+	if len(cfg.AccessKeys) > 0 && activeAccessKey(cfg).PrivateKeyPath == "" {
+		return fmt.Errorf("active access key %q has no private key", activeAccessKey(cfg).ID)
+	}
+
+	return nil
+}
+
+// The access key signing new tokens
+func activeAccessKey(cfg Config) AccessKey {
+	for _, key := range cfg.AccessKeys {
+		if key.ID == cfg.ActiveAccessKeyID {
+			return key
+		}
+	}
+
+	return cfg.AccessKeys[0]
 }
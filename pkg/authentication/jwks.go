@@ -0,0 +1,54 @@
+package authentication
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"sort"
+)
+
+// JWK is a public key verifying access tokens, as a JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JWKS is the set of public keys verifying access tokens, which other
+// services verify storefront tokens with
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Return the public keys of the access keys, so that they can be published
+func (bkr *Broker) PublicKeys(ctx context.Context) JWKS {
+	keys := bkr.keys.Load()
+
+	jwks := JWKS{Keys: make([]JWK, 0, len(keys.access))}
+	for kid, key := range keys.access {
+		jwks.Keys = append(jwks.Keys, newJWK(kid, key, bkr.cfg.Algorithms[0]))
+	}
+
+	// Keep the document stable between requests
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].Kid < jwks.Keys[j].Kid
+	})
+
+	return jwks
+}
+
+// The key is published with the algorithm access tokens are signed with
+func newJWK(kid string, key *rsa.PublicKey, alg string) JWK {
+	return JWK{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: alg,
+		Kid: kid,
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
@@ -0,0 +1,115 @@
+package authentication
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestRotateKeys(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	oldKey := newTestKey(t, dir, "2024-01")
+	newKey := newTestKey(t, dir, "2024-06")
+
+	// A server still signing with the old key, and one signing with the new key
+	before, _ := newTestBroker(t, Config{AccessKeys: []AccessKey{oldKey, newKey}})
+	after, _ := newTestBroker(t, Config{AccessKeys: []AccessKey{oldKey, newKey}, ActiveAccessKeyID: newKey.ID})
+
+	oldToken, err := before.GenerateAccessToken(ctx, dtos.User{Id: "user-id"})
+	if err != nil {
+		t.Fatalf("couldn't generate token: %s", err.Error())
+	}
+	newToken, err := after.GenerateAccessToken(ctx, dtos.User{Id: "user-id"})
+	if err != nil {
+		t.Fatalf("couldn't generate token: %s", err.Error())
+	}
+
+	for name, token := range map[string]string{"old": oldToken, "new": newToken} {
+		if _, err := after.ParseJWT(ctx, token); err != nil {
+			t.Fatalf("expected the %s token to be valid but got %v", name, err)
+		}
+	}
+
+	// Tokens name the key they were signed with
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &JwtClaims{})
+	if err != nil || parsed.Header["kid"] != newKey.ID {
+		t.Fatalf("expected the token to be signed with %s but got %v (%v)", newKey.ID, parsed.Header["kid"], err)
+	}
+
+	// Once the old key is removed, the tokens it signed are rejected
+	removed, _ := newTestBroker(t, Config{AccessKeys: []AccessKey{newKey}})
+	if _, err := removed.ParseJWT(ctx, oldToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected %v but got %v", ErrInvalidToken, err)
+	}
+}
+
+func TestPublicKeys(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	oldKey := newTestKey(t, dir, "2024-01")
+	newKey := newTestKey(t, dir, "2024-06")
+
+	bkr, _ := newTestBroker(t, Config{AccessKeys: []AccessKey{newKey, oldKey}, ActiveAccessKeyID: newKey.ID})
+
+	jwks := bkr.PublicKeys(context.Background())
+	if len(jwks.Keys) != 2 || jwks.Keys[0].Kid != oldKey.ID || jwks.Keys[1].Kid != newKey.ID {
+		t.Fatalf("expected the keys %s and %s but got %+v", oldKey.ID, newKey.ID, jwks.Keys)
+	}
+
+	// The published key is the one verifying the tokens
+	jwk := jwks.Keys[1]
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		t.Fatalf("couldn't decode modulus: %s", err.Error())
+	}
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil {
+		t.Fatalf("couldn't decode exponent: %s", err.Error())
+	}
+
+	key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	if !key.Equal(bkr.keys.Load().access[newKey.ID]) || jwk.Kty != "RSA" || jwk.Alg != "RS256" {
+		t.Fatalf("unexpected key %+v", jwk)
+	}
+
+	// Keys are published with the algorithm signing the tokens
+	pss, _ := newTestBroker(t, Config{AccessKeys: []AccessKey{newKey}, Algorithms: []string{"PS256", "RS256"}})
+	for _, jwk := range pss.PublicKeys(context.Background()).Keys {
+		if jwk.Alg != "PS256" {
+			t.Fatalf("expected the key to be published for PS256 but got %s", jwk.Alg)
+		}
+	}
+}
+
+func TestValidateKeys(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]Config{
+		"no ID":          {AccessKeys: []AccessKey{{PrivateKeyPath: "a.private", PublicKeyPath: "a.public"}}},
+		"duplicate ID":   {AccessKeys: []AccessKey{{ID: "a", PrivateKeyPath: "a.private", PublicKeyPath: "a.public"}, {ID: "a", PublicKeyPath: "b.public"}}},
+		"no public key":  {AccessKeys: []AccessKey{{ID: "a", PrivateKeyPath: "a.private"}}},
+		"unknown active": {AccessKeys: []AccessKey{{ID: "a", PrivateKeyPath: "a.private", PublicKeyPath: "a.public"}}, ActiveAccessKeyID: "b"},
+		"no private key": {AccessKeys: []AccessKey{{ID: "a", PrivateKeyPath: "a.private", PublicKeyPath: "a.public"}, {ID: "b", PublicKeyPath: "b.public"}}, ActiveAccessKeyID: "b"},
+	}
+
+	for name, cfg := range tests {
+		cfg := cfg
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := validateConfig(cfg); err == nil {
+				t.Fatal("expected the configuration to be invalid")
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package authentication
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// keySet is the keys of the agent as of when they were last loaded
+type keySet struct {
+	// Key signing new access tokens and the ID its tokens are marked with
+	signing   *rsa.PrivateKey
+	signingID string
+
+	// Keys verifying access tokens, by key ID
+	access map[string]*rsa.PublicKey
+
+	internalPublic *rsa.PublicKey
+}
+
+// Read and parse the keys at the configured paths
+func loadKeys(cfg Config) (*keySet, error) {
+	keys := &keySet{access: make(map[string]*rsa.PublicKey, len(cfg.AccessKeys))}
+
+	for _, key := range cfg.AccessKeys {
+		verifyBytes, err := readKey(key.PublicKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		keys.access[key.ID], err = jwt.ParseRSAPublicKeyFromPEM(verifyBytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w %s: %v", ErrParsingKey, key.PublicKeyPath, err)
+		}
+	}
+
+	active := activeAccessKey(cfg)
+	signBytes, err := readKey(active.PrivateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	keys.signing, err = jwt.ParseRSAPrivateKeyFromPEM(signBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %v", ErrParsingKey, active.PrivateKeyPath, err)
+	}
+	keys.signingID = active.ID
+
+	// Tokens signed with a key its public key doesn't verify would be rejected
+	if !keys.signing.PublicKey.Equal(keys.access[active.ID]) {
+		return nil, fmt.Errorf("%w: private key of access key %q doesn't match its public key", ErrParsingKey, active.ID)
+	}
+
+	internalBytes, err := readKey(cfg.InternalPublicKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	keys.internalPublic, err = jwt.ParseRSAPublicKeyFromPEM(internalBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %v", ErrParsingKey, cfg.InternalPublicKeyPath, err)
+	}
+
+	return keys, nil
+}
+
+func readKey(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %v", ErrReadingKey, path, err)
+	}
+
+	return b, nil
+}
+
+// Load the keys again, keeping the current ones if the new ones are invalid
+func (bkr *Broker) reload() {
+	keys, err := loadKeys(bkr.cfg)
+	if err != nil {
+		log.Error().Err(err).Msg("Error reloading keys, keeping the current keys")
+		return
+	}
+
+	bkr.keys.Store(keys)
+	log.Info().Msg("Reloaded keys")
+}
+
+// Paths of the files the keys are loaded from
+func (bkr *Broker) keyPaths() []string {
+	paths := []string{bkr.cfg.InternalPublicKeyPath, activeAccessKey(bkr.cfg).PrivateKeyPath}
+	for _, key := range bkr.cfg.AccessKeys {
+		paths = append(paths, key.PublicKeyPath)
+	}
+
+	return paths
+}
+
+// Reload the keys whenever their files change or the process receives SIGHUP,
+// until the agent is closed
+func (bkr *Broker) watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	// Directories are watched rather than the files, so that keys replaced by
+	// renaming a new file over them are still noticed
+	paths := make(map[string]bool)
+	for _, path := range bkr.keyPaths() {
+		path = filepath.Clean(path)
+		if paths[path] {
+			continue
+		}
+		paths[path] = true
+
+		err = watcher.Add(filepath.Dir(path))
+		if err != nil {
+			watcher.Close()
+			return fmt.Errorf("watching %s: %w", filepath.Dir(path), err)
+		}
+	}
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hangup)
+
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if paths[filepath.Clean(event.Name)] && !event.Has(fsnotify.Chmod) {
+					bkr.reload()
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Error().Err(err).Msg("Error watching keys")
+			case <-hangup:
+				bkr.reload()
+			case <-bkr.done:
+				return
+			}
+		}
+	}()
+
+	bkr.watcher = watcher
+
+	return nil
+}
+
+// Close stops reloading the keys of the agent. Closing it again does nothing.
+func (bkr *Broker) Close() error {
+	var err error
+	bkr.closed.Do(func() {
+		close(bkr.done)
+		err = bkr.watcher.Close()
+	})
+
+	return err
+}
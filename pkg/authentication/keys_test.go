@@ -0,0 +1,172 @@
+package authentication
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
+)
+
+// Write a new key pair, replacing the files like a deployment would
+func writeKeyPair(t *testing.T, privatePath string, publicPath string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("couldn't generate key: %s", err.Error())
+	}
+
+	publicBytes, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("couldn't encode public key: %s", err.Error())
+	}
+
+	writeFile(t, privatePath, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	writeFile(t, publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicBytes}))
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		t.Fatalf("couldn't write %s: %s", tmp, err.Error())
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatalf("couldn't replace %s: %s", path, err.Error())
+	}
+}
+
+// Write a new access key to a directory
+func newTestKey(t *testing.T, dir string, id string) AccessKey {
+	t.Helper()
+
+	key := AccessKey{
+		ID:             id,
+		PrivateKeyPath: filepath.Join(dir, id+".private"),
+		PublicKeyPath:  filepath.Join(dir, id+".public"),
+	}
+	writeKeyPair(t, key.PrivateKeyPath, key.PublicKeyPath)
+
+	return key
+}
+
+// Create an agent with the configured keys, or new ones in a temporary
+// directory
+func newTestBroker(t *testing.T, cfg Config) (*Broker, Config) {
+	t.Helper()
+
+	dir := t.TempDir()
+	if len(cfg.AccessKeys) == 0 {
+		cfg.AccessKeys = []AccessKey{newTestKey(t, dir, "access-key")}
+	}
+	if cfg.InternalPublicKeyPath == "" {
+		cfg.InternalPublicKeyPath = newTestKey(t, dir, "internal-key").PublicKeyPath
+	}
+
+	auth, err := New(cfg)
+	if err != nil {
+		t.Fatalf("couldn't create agent: %s", err.Error())
+	}
+
+	bkr := auth.(*Broker)
+	t.Cleanup(func() { bkr.Close() })
+
+	return bkr, cfg
+}
+
+func TestCloseTwice(t *testing.T) {
+	t.Parallel()
+
+	bkr, _ := newTestBroker(t, Config{})
+
+	// e.g. a deferred close and the shutdown of the server
+	for i := 0; i < 2; i++ {
+		if err := bkr.Close(); err != nil {
+			t.Fatalf("expected closing the agent to succeed but got %s", err.Error())
+		}
+	}
+}
+
+func TestNewMissingKeys(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	_, err := New(Config{
+		AccessKeys:            []AccessKey{{ID: "missing", PrivateKeyPath: filepath.Join(dir, "missing.private"), PublicKeyPath: filepath.Join(dir, "missing.public")}},
+		InternalPublicKeyPath: filepath.Join(dir, "missing.public"),
+	})
+	if !errors.Is(err, ErrReadingKey) {
+		t.Fatalf("expected %v but got %v", ErrReadingKey, err)
+	}
+}
+
+func TestNewMismatchedKeys(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	key := newTestKey(t, dir, "access-key")
+	key.PublicKeyPath = newTestKey(t, dir, "other-key").PublicKeyPath
+
+	_, err := New(Config{AccessKeys: []AccessKey{key}, InternalPublicKeyPath: key.PublicKeyPath})
+	if !errors.Is(err, ErrParsingKey) {
+		t.Fatalf("expected %v but got %v", ErrParsingKey, err)
+	}
+}
+
+func TestReloadKeys(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	bkr, cfg := newTestBroker(t, Config{})
+	key := cfg.AccessKeys[0]
+
+	token, err := bkr.GenerateAccessToken(ctx, dtos.User{Id: "user-id"})
+	if err != nil {
+		t.Fatalf("couldn't generate token: %s", err.Error())
+	}
+
+	if _, err := bkr.ParseJWT(ctx, token); err != nil {
+		t.Fatalf("expected the token to be valid but got %v", err)
+	}
+
+	// Invalid keys are not loaded
+	writeFile(t, key.PublicKeyPath, []byte("not a key"))
+	bkr.reload()
+
+	if _, err := bkr.ParseJWT(ctx, token); err != nil {
+		t.Fatalf("expected the current keys to be kept but got %v", err)
+	}
+
+	// Replacing the key files rotates the keys
+	writeKeyPair(t, key.PrivateKeyPath, key.PublicKeyPath)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, err := bkr.ParseJWT(ctx, token)
+		if errors.Is(err, ErrInvalidToken) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the keys to be reloaded but the old token is still accepted (%v)", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	token, err = bkr.GenerateAccessToken(ctx, dtos.User{Id: "user-id"})
+	if err != nil {
+		t.Fatalf("couldn't generate token: %s", err.Error())
+	}
+
+	if _, err := bkr.ParseJWT(ctx, token); err != nil {
+		t.Fatalf("expected a token signed with the new keys to be valid but got %v", err)
+	}
+}
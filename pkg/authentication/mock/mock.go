@@ -1,13 +1,26 @@
 package mock
 
 import (
+	"context"
+	"time"
+
+	"github.com/anthonydip/flutter-messenger-go/pkg/authentication"
 	"github.com/anthonydip/flutter-messenger-go/pkg/dtos"
 )
 
 type Result func(c *mockConfig)
 
 type mockConfig struct {
-	validateJWTShouldFail bool
+	parseJWTShouldFail bool
+}
+
+// Principal of the user tokens parsed by the mock
+var Principal = authentication.Principal{
+	UserID:    "8ae84a23-fa49-45eb-8000-bdc9b9fe074a",
+	Email:     "mock@storefront-mock.com",
+	Provider:  "Flutter",
+	TokenType: authentication.TokenTypeUser,
+	TokenID:   "5b9f3c2e-7d41-4a8e-9c6b-2f0e8a1d4c73",
 }
 
 // Mock the Authorization agent
@@ -28,24 +41,47 @@ func New(opts ...Result) *Mock {
 	return r
 }
 
-func (m Mock) GenerateAccessToken(dtos.User) (string, error) {
+func (m Mock) GenerateAccessToken(context.Context, dtos.User) (string, error) {
 	return "some-access-token", nil
 }
 
-func (m Mock) ValidateJWT(string) bool {
-	return !m.cfg.validateJWTShouldFail
+func (m Mock) GenerateRefreshToken(context.Context) (string, time.Time, error) {
+	return "some-refresh-token", time.Now().Add(time.Hour), nil
 }
 
-func ValidateJWTFail() Result {
+func (m Mock) TokenID(context.Context, string) (string, error) {
+	return Principal.TokenID, nil
+}
+
+func (m Mock) ParseJWT(context.Context, string) (authentication.Principal, error) {
+	if m.cfg.parseJWTShouldFail {
+		return authentication.Principal{}, authentication.ErrInvalidToken
+	}
+
+	return Principal, nil
+}
+
+func ParseJWTFail() Result {
 	return func(c *mockConfig) {
-		c.validateJWTShouldFail = true
+		c.parseJWTShouldFail = true
 	}
 }
 
-func (m Mock) ValidateParseJWT(string) (dtos.User, error) {
-	return dtos.User{}, nil
+func (m Mock) ParseInternalJWT(context.Context, string) (authentication.Principal, error) {
+	return authentication.Principal{TokenType: authentication.TokenTypeInternal}, nil
+}
+
+func (m Mock) PublicKeys(context.Context) authentication.JWKS {
+	return authentication.JWKS{Keys: []authentication.JWK{{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: "RS256",
+		Kid: "rsa-access-key",
+		N:   "sXchDaQebHnPiGvyDOAT4saGEUetSyo9MKLOoWFsueri23bOdgWp4Dy1WlUzewbgBHod5pcM9H95GQRV3JDXboIRROSBigeC5yjU1hGzHHyXss8UDprecbAYxknTcQkhslANGRUZmdTOQ5qTRsLAt6BTYuyvVRdhS8exSZEy_c4gs_7svlJJQ4H9_NxsiIoLwAEk7-Q3UXERGYw_75IDrGA84-lA_-Ct4eTlXHBIY2EaV7t7LjJaynVJCpkv4LKjTTAumiGUIuQhrNhZLuF_RJLqHpM2kgWFLU7-VTdL1VbC2tejvcI2BlMkEpk1BzBZI0KQB0GaDWFLN-aEAw3vRw",
+		E:   "AQAB",
+	}}}
 }
 
-func (m Mock) ValidateInternalJWT(string) bool {
-	return true
+func (m Mock) Close() error {
+	return nil
 }
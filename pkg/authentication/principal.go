@@ -0,0 +1,48 @@
+package authentication
+
+import (
+	"context"
+)
+
+// Token types of the access tokens issued to users, and of the tokens other
+// services call internal routes with
+const (
+	TokenTypeUser     = "user"
+	TokenTypeInternal = "internal"
+)
+
+// Principal is the caller a JWT was issued to
+type Principal struct {
+	UserID    string
+	Email     string
+	Provider  string
+	TokenType string
+	Scopes    []string
+
+	// ID (jti) of the token, which it is revoked by
+	TokenID string
+}
+
+type principalKey struct{}
+
+// Return a copy of the context carrying the principal of its request
+func NewContext(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// Return the principal of a request, set by the authentication middleware
+func FromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+func principalFromClaims(claims *JwtClaims) Principal {
+	return Principal{
+		UserID:    claims.UserID,
+		Email:     claims.Email,
+		Provider:  claims.Provider,
+		TokenType: claims.TokenType,
+		Scopes:    claims.Scopes,
+		TokenID:   claims.ID,
+	}
+}
@@ -0,0 +1,14 @@
+package dtos
+
+import (
+	"fmt"
+)
+
+type BlockedUser struct {
+	Id        string `firestore:"id,omitempty" json:"id,omitempty"`
+	Timestamp int64  `firestore:"timestamp,omitempty" json:"timestamp,omitempty"`
+}
+
+func (blocked BlockedUser) String() string {
+	return fmt.Sprintf("BlockedUser{Id: %s, Timestamp: %d}", blocked.Id, blocked.Timestamp)
+}
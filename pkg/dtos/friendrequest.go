@@ -0,0 +1,22 @@
+package dtos
+
+import (
+	"fmt"
+)
+
+// Directions of a user's friend requests
+const (
+	FriendRequestIncoming = "incoming"
+	FriendRequestOutgoing = "outgoing"
+)
+
+type FriendRequest struct {
+	Id        string `firestore:"id,omitempty" json:"id,omitempty"`
+	From      string `firestore:"from,omitempty" json:"from,omitempty"`
+	To        string `firestore:"to,omitempty" json:"to,omitempty"`
+	Timestamp int64  `firestore:"timestamp,omitempty" json:"timestamp,omitempty"`
+}
+
+func (request FriendRequest) String() string {
+	return fmt.Sprintf("FriendRequest{Id: %s, From: %s, To: %s, Timestamp: %d}", request.Id, request.From, request.To, request.Timestamp)
+}
@@ -0,0 +1,21 @@
+package dtos
+
+import (
+	"fmt"
+)
+
+// Roles of a group member
+const (
+	GroupAdmin  = "admin"
+	GroupMember = "member"
+)
+
+type Group struct {
+	Id      string            `firestore:"id,omitempty" json:"id,omitempty"`
+	Name    string            `firestore:"name,omitempty" json:"name,omitempty"`
+	Members map[string]string `firestore:"members,omitempty" json:"members,omitempty"`
+}
+
+func (group Group) String() string {
+	return fmt.Sprintf("Group{Id: %s, Name: %s, Members: %d}", group.Id, group.Name, len(group.Members))
+}
@@ -0,0 +1,28 @@
+package dtos
+
+import (
+	"fmt"
+)
+
+// Delivery states of a message, in the order a message goes through them
+const (
+	MessageSent      = "sent"
+	MessageDelivered = "delivered"
+	MessageRead      = "read"
+)
+
+type Message struct {
+	Id              string `firestore:"id,omitempty" json:"id,omitempty"`
+	ConversationId  string `firestore:"conversationId,omitempty" json:"conversationId,omitempty"`
+	From            string `firestore:"from,omitempty" json:"from,omitempty"`
+	To              string `firestore:"to,omitempty" json:"to,omitempty"`
+	GroupId         string `firestore:"groupId,omitempty" json:"groupId,omitempty"`
+	Body            string `firestore:"body,omitempty" json:"body,omitempty"`
+	Timestamp       int64  `firestore:"timestamp,omitempty" json:"timestamp,omitempty"`
+	ServerTimestamp int64  `firestore:"serverTimestamp,omitempty" json:"serverTimestamp,omitempty"`
+	Status          string `firestore:"status,omitempty" json:"status,omitempty"`
+}
+
+func (message Message) String() string {
+	return fmt.Sprintf("Message{Id: %s, ConversationId: %s, From: %s, To: %s, Status: %s}", message.Id, message.ConversationId, message.From, message.To, message.Status)
+}
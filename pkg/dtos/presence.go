@@ -0,0 +1,22 @@
+package dtos
+
+import (
+	"fmt"
+)
+
+// Presence states of a user
+const (
+	PresenceOnline  = "online"
+	PresenceAway    = "away"
+	PresenceOffline = "offline"
+)
+
+type Presence struct {
+	Id       string `firestore:"id,omitempty" json:"id,omitempty"`
+	Status   string `firestore:"status,omitempty" json:"status,omitempty"`
+	LastSeen int64  `firestore:"lastSeen,omitempty" json:"lastSeen,omitempty"`
+}
+
+func (presence Presence) String() string {
+	return fmt.Sprintf("Presence{Id: %s, Status: %s, LastSeen: %d}", presence.Id, presence.Status, presence.LastSeen)
+}